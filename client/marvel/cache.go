@@ -0,0 +1,127 @@
+package marvel
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Cache stores api responses along with their etag.
+type Cache interface {
+	// Get returns etag and body cached with key, ok is false if not cached.
+	Get(key string) (etag string, body []byte, ok bool)
+	// Set caches etag and body with key.
+	Set(key, etag string, body []byte) error
+}
+
+// MemoryCache is a Cache kept in memory.
+type MemoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	etag string
+	body []byte
+}
+
+// NewMemoryCache returns an empty MemoryCache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]*cacheEntry)}
+}
+
+// Get returns etag and body cached with key.
+func (mc *MemoryCache) Get(key string) (string, []byte, bool) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	entry, ok := mc.entries[key]
+	if !ok {
+		return "", nil, false
+	}
+
+	return entry.etag, entry.body, true
+}
+
+// Set caches etag and body with key.
+func (mc *MemoryCache) Set(key, etag string, body []byte) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.entries[key] = &cacheEntry{etag: etag, body: body}
+
+	return nil
+}
+
+// FileCache is a Cache kept on filesystem, one file per key.
+// Each file holds the etag on its first line followed by the body.
+type FileCache struct {
+	dir string
+}
+
+// NewFileCache returns a FileCache in dir, creating dir if not existing.
+func NewFileCache(dir string) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating cache dir %q: %v", dir, err)
+	}
+
+	return &FileCache{dir: dir}, nil
+}
+
+// Get returns etag and body cached with key.
+func (fc *FileCache) Get(key string) (string, []byte, bool) {
+	b, err := ioutil.ReadFile(fc.path(key))
+	if err != nil {
+		return "", nil, false
+	}
+
+	i := bytes.IndexByte(b, '\n')
+	if i < 0 {
+		return "", nil, false
+	}
+
+	return string(b[:i]), b[i+1:], true
+}
+
+// Set caches etag and body with key.
+// It writes to a temporary file first so a concurrent Get never sees a partial file.
+func (fc *FileCache) Set(key, etag string, body []byte) error {
+	f, err := ioutil.TempFile(fc.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(append([]byte(etag+"\n"), body...))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), fc.path(key))
+}
+
+func (fc *FileCache) path(key string) string {
+	return filepath.Join(fc.dir, fmt.Sprintf("%x", sha1.Sum([]byte(key))))
+}
+
+// cacheKey returns the key of a request by path and query, ignoring auth params changing on every request.
+func cacheKey(path string, query url.Values) string {
+	q := url.Values{}
+	for k, v := range query {
+		switch k {
+		case "apikey", "hash", "ts":
+			continue
+		}
+		q[k] = v
+	}
+
+	return path + "?" + q.Encode()
+}
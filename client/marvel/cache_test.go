@@ -0,0 +1,100 @@
+package marvel
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "marvel-cache")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	fc, err := NewFileCache(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		desc  string
+		cache Cache
+	}{
+		{desc: "Memory", cache: NewMemoryCache()},
+		{desc: "File", cache: fc},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if _, _, ok := tc.cache.Get("foo"); ok {
+				t.Fatal("got cached entry before set")
+			}
+
+			if err := tc.cache.Set("foo", "etag", []byte("line\nbody")); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			etag, body, ok := tc.cache.Get("foo")
+			if !ok {
+				t.Fatal("got no cached entry after set")
+			}
+
+			if got, want := etag, "etag"; got != want {
+				t.Errorf("got etag %q, want %q", got, want)
+			}
+
+			if got, want := string(body), "line\nbody"; got != want {
+				t.Errorf("got body %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func Test_CacheKey(t *testing.T) {
+	a := cacheKey("comics", url.Values{"apikey": {"a"}, "hash": {"b"}, "ts": {"1"}, "limit": {"10"}})
+	b := cacheKey("comics", url.Values{"apikey": {"c"}, "hash": {"d"}, "ts": {"2"}, "limit": {"10"}})
+
+	if got, want := a, "comics?limit=10"; got != want {
+		t.Errorf("got key %q, want %q", got, want)
+	}
+
+	if a != b {
+		t.Errorf("got different keys %q and %q, want same", a, b)
+	}
+}
+
+func TestClient_GetWithCache(t *testing.T) {
+	var gotIfNoneMatch []string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotIfNoneMatch = append(gotIfNoneMatch, r.Header.Get("If-None-Match"))
+
+		if r.Header.Get("If-None-Match") == "foo" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Write([]byte(`{"etag":"foo","data":{"total":1}}`))
+	}))
+
+	c := NewClient(ts.URL, "", "", WithCache(NewMemoryCache()))
+
+	for i := 0; i < 2; i++ {
+		b, err := c.get(context.Background(), &Params{typ: "foo"})
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if got, want := string(b), `{"etag":"foo","data":{"total":1}}`; got != want {
+			t.Errorf("got body %q, want %q", got, want)
+		}
+	}
+
+	if got, want := gotIfNoneMatch, []string{"", "foo"}; len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got If-None-Match %q, want %q", got, want)
+	}
+}
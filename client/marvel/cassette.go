@@ -0,0 +1,160 @@
+package marvel
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// CassetteMode tells whether a Cassette records or replays responses.
+type CassetteMode int
+
+// Modes of Cassette.
+const (
+	CassetteReplay CassetteMode = iota // serve recorded responses without network
+	CassetteRecord                     // send requests and record their responses
+)
+
+// Cassette is a http.RoundTripper recording api responses into a directory or replaying them from it.
+// Requests are matched by method, path and query, without the auth params apikey, hash and ts.
+type Cassette struct {
+	dir       string
+	mode      CassetteMode
+	transport http.RoundTripper
+}
+
+// cassetteEntry is one recorded response, kept as one json file.
+type cassetteEntry struct {
+	Key    string          `json:"key"`
+	Status int             `json:"status"`
+	Header http.Header     `json:"header,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"` // json body kept as is to stay readable
+	Text   string          `json:"text,omitempty"` // non-json body, e.g. gateway errors
+}
+
+// NewCassette returns a Cassette in dir with mode, creating dir if not existing.
+func NewCassette(dir string, mode CassetteMode) (*Cassette, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating cassette dir %q: %v", dir, err)
+	}
+
+	return &Cassette{dir: dir, mode: mode, transport: http.DefaultTransport}, nil
+}
+
+// WithCassette makes Client send requests through cassette.
+func WithCassette(cassette *Cassette) Option {
+	return func(c *Client) {
+		c.hc.Transport = cassette
+	}
+}
+
+// RoundTrip replays the recorded response of req, or sends req and records its response.
+func (cs *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := req.Method + " " + cacheKey(req.URL.Path, req.URL.Query())
+
+	if cs.mode == CassetteReplay {
+		return cs.replay(req, key)
+	}
+
+	resp, err := cs.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cs.save(key, resp.StatusCode, resp.Header, b); err != nil {
+		return nil, fmt.Errorf("error recording %q: %v", key, err)
+	}
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(b))
+
+	return resp, nil
+}
+
+// Add records body with status as the response of GET path with query,
+// e.g. to replay json fixtures saved from the api.
+func (cs *Cassette) Add(path string, query url.Values, status int, body []byte) error {
+	return cs.save(http.MethodGet+" "+cacheKey(path, query), status, nil, body)
+}
+
+func (cs *Cassette) replay(req *http.Request, key string) (*http.Response, error) {
+	b, err := ioutil.ReadFile(cs.path(key))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no recorded response for %q", key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entry cassetteEntry
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return nil, fmt.Errorf("error decoding recorded response for %q: %v", key, err)
+	}
+
+	header := entry.Header
+	if header == nil {
+		header = http.Header{}
+	}
+
+	body := []byte(entry.Body)
+	if entry.Body == nil {
+		body = []byte(entry.Text)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", entry.Status, http.StatusText(entry.Status)),
+		StatusCode:    entry.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func (cs *Cassette) save(key string, status int, header http.Header, body []byte) error {
+	entry := &cassetteEntry{Key: key, Status: status, Header: header}
+	if json.Valid(body) {
+		entry.Body = body
+	} else {
+		entry.Text = string(body)
+	}
+
+	b, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(cs.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), cs.path(key))
+}
+
+func (cs *Cassette) path(key string) string {
+	return filepath.Join(cs.dir, fmt.Sprintf("%x.json", sha1.Sum([]byte(key))))
+}
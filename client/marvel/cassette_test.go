@@ -0,0 +1,110 @@
+package marvel
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+)
+
+func TestCassette(t *testing.T) {
+	dir, err := ioutil.TempDir("", "marvel-cassette")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/comics" {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte("not json"))
+			return
+		}
+
+		w.Write([]byte(`{"data":{"total":2,"results":[{"id":1},{"id":2}]}}`))
+	}))
+
+	record, err := NewCassette(dir, CassetteRecord)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c := NewClient(ts.URL, "private", "public", WithCassette(record))
+
+	if _, err := c.GetCharacters(context.Background(), &Params{Limit: 2}); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if _, err := c.GetComics(context.Background(), &Params{}); err == nil {
+		t.Fatal("error is nil")
+	}
+
+	ts.Close()
+
+	replay, err := NewCassette(dir, CassetteReplay)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// auth params differ on replay
+	c = NewClient(ts.URL, "other", "other", WithCassette(replay))
+
+	t.Run("Success", func(t *testing.T) {
+		page, err := c.GetCharacters(context.Background(), &Params{Limit: 2})
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if got, want := len(page.Results), 2; got != want {
+			t.Errorf("got %d characters, want %d", got, want)
+		}
+	})
+
+	t.Run("APIError", func(t *testing.T) {
+		_, err := c.GetComics(context.Background(), &Params{})
+
+		var apiErr *APIError
+		ok := errors.As(err, &apiErr)
+		if !ok {
+			t.Fatalf("got error type %T, want %T", err, apiErr)
+		}
+
+		if got, want := apiErr.Code, http.StatusConflict; got != want {
+			t.Errorf("got code %d, want %d", got, want)
+		}
+
+		if got, want := apiErr.Message, "not json"; got != want {
+			t.Errorf("got message %q, want %q", got, want)
+		}
+	})
+
+	t.Run("NotRecorded", func(t *testing.T) {
+		if _, err := c.GetCharacters(context.Background(), &Params{Limit: 3}); err == nil {
+			t.Fatal("error is nil")
+		}
+	})
+
+	t.Run("Fixture", func(t *testing.T) {
+		b, err := ioutil.ReadFile("data/characters.json")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := replay.Add("/characters", url.Values{"limit": {"100"}}, http.StatusOK, b); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		page, err := c.GetCharacters(context.Background(), &Params{Limit: 100})
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if len(page.Results) == 0 {
+			t.Error("got no characters")
+		}
+	})
+}
@@ -33,8 +33,8 @@ func (c *Client) GetCharacter(ctx context.Context, id int) (*Character, error) {
 	return data.Data.Results[0], nil
 }
 
-// GetCharacters returns list of character with given Params.
-func (c *Client) GetCharacters(ctx context.Context, params *Params) ([]*Character, error) {
+// GetCharacters returns a page of character with given Params.
+func (c *Client) GetCharacters(ctx context.Context, params *Params) (*CharacterPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -44,8 +44,8 @@ func (c *Client) GetCharacters(ctx context.Context, params *Params) ([]*Characte
 	return c.getCharacters(ctx, params)
 }
 
-// GetComicCharacters returns list of character filtered by comic ID.
-func (c *Client) GetComicCharacters(ctx context.Context, id int, params *Params) ([]*Character, error) {
+// GetComicCharacters returns a page of character filtered by comic ID.
+func (c *Client) GetComicCharacters(ctx context.Context, id int, params *Params) (*CharacterPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -57,8 +57,8 @@ func (c *Client) GetComicCharacters(ctx context.Context, id int, params *Params)
 	return c.getCharacters(ctx, params)
 }
 
-// GetEventCharacters returns list of character filtered by event ID.
-func (c *Client) GetEventCharacters(ctx context.Context, id int, params *Params) ([]*Character, error) {
+// GetEventCharacters returns a page of character filtered by event ID.
+func (c *Client) GetEventCharacters(ctx context.Context, id int, params *Params) (*CharacterPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -70,8 +70,8 @@ func (c *Client) GetEventCharacters(ctx context.Context, id int, params *Params)
 	return c.getCharacters(ctx, params)
 }
 
-// GetSeriesCharacters returns list of character filtered by series ID.
-func (c *Client) GetSeriesCharacters(ctx context.Context, id int, params *Params) ([]*Character, error) {
+// GetSeriesCharacters returns a page of character filtered by series ID.
+func (c *Client) GetSeriesCharacters(ctx context.Context, id int, params *Params) (*CharacterPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -83,8 +83,8 @@ func (c *Client) GetSeriesCharacters(ctx context.Context, id int, params *Params
 	return c.getCharacters(ctx, params)
 }
 
-// GetStoryCharacters returns list of character filtered by story ID.
-func (c *Client) GetStoryCharacters(ctx context.Context, id int, params *Params) ([]*Character, error) {
+// GetStoryCharacters returns a page of character filtered by story ID.
+func (c *Client) GetStoryCharacters(ctx context.Context, id int, params *Params) (*CharacterPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -96,24 +96,20 @@ func (c *Client) GetStoryCharacters(ctx context.Context, id int, params *Params)
 	return c.getCharacters(ctx, params)
 }
 
-// getCharacters returns a list of character with given Params.
+// getCharacters returns a page of character with given Params.
 // If Params.subTyp is set, it returns characters filtered by Params.Type and Params.ID.
-func (c *Client) getCharacters(ctx context.Context, params *Params) ([]*Character, error) {
+func (c *Client) getCharacters(ctx context.Context, params *Params) (*CharacterPage, error) {
 	resp, err := c.get(ctx, params)
 	if err != nil {
 		return nil, err
 	}
 
-	var data struct {
-		Data struct {
-			Results []*Character
-		}
-	}
+	page := &CharacterPage{}
 
-	err = json.Unmarshal(resp, &data)
+	err = decodePage(resp, &page.Page, &page.Results)
 	if err != nil {
 		return nil, err
 	}
 
-	return data.Data.Results, nil
+	return page, nil
 }
@@ -3,6 +3,7 @@ package marvel
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -66,7 +67,8 @@ func TestClient_GetCharacter(t *testing.T) {
 			t.Fatal("error is nil")
 		}
 
-		ae, ok := err.(*APIError)
+		var ae *APIError
+		ok := errors.As(err, &ae)
 
 		if !ok {
 			t.Fatalf("got error type %T, want %T", ae, err)
@@ -107,7 +109,7 @@ func TestClient_GetCharacters(t *testing.T) {
 
 		c := NewClient(ts.URL, "", "")
 
-		gotChars, err := c.GetCharacters(context.Background(), &Params{Limit: 1, Offset: 2})
+		gotPage, err := c.GetCharacters(context.Background(), &Params{Limit: 1, Offset: 2})
 		if err != nil {
 			t.Fatalf("unexpected err: %v", err)
 		}
@@ -133,12 +135,12 @@ func TestClient_GetCharacters(t *testing.T) {
 			t.Errorf("got query parameter offset %q, want %q", got, want)
 		}
 
-		if got, want := len(gotChars), len(wantChars); got != want {
+		if got, want := len(gotPage.Results), len(wantChars); got != want {
 			t.Errorf("got %d characters, want %d", got, want)
 		}
 
 		for i, char := range wantChars {
-			if got, want := gotChars[i].ID, char.ID; got != want {
+			if got, want := gotPage.Results[i].ID, char.ID; got != want {
 				t.Errorf("got chars[%d].ID %d, want %d", i, got, want)
 			}
 		}
@@ -171,7 +173,8 @@ func TestClient_GetCharacters(t *testing.T) {
 			t.Fatal("error is nil")
 		}
 
-		ae, ok := err.(*APIError)
+		var ae *APIError
+		ok := errors.As(err, &ae)
 
 		if !ok {
 			t.Fatalf("got error type %T, want %T", ae, err)
@@ -212,7 +215,7 @@ func TestClient_GetComicCharacters(t *testing.T) {
 
 		c := NewClient(ts.URL, "", "")
 
-		gotChars, err := c.GetComicCharacters(context.Background(), 123, &Params{Limit: 1, Offset: 2})
+		gotPage, err := c.GetComicCharacters(context.Background(), 123, &Params{Limit: 1, Offset: 2})
 		if err != nil {
 			t.Fatalf("unexpected err: %v", err)
 		}
@@ -238,12 +241,12 @@ func TestClient_GetComicCharacters(t *testing.T) {
 			t.Errorf("got query parameter offset %q, want %q", got, want)
 		}
 
-		if got, want := len(gotChars), len(wantChars); got != want {
+		if got, want := len(gotPage.Results), len(wantChars); got != want {
 			t.Errorf("got %d characters, want %d", got, want)
 		}
 
 		for i, char := range wantChars {
-			if got, want := gotChars[i].ID, char.ID; got != want {
+			if got, want := gotPage.Results[i].ID, char.ID; got != want {
 				t.Errorf("got chars[%d].ID %d, want %d", i, got, want)
 			}
 		}
@@ -276,7 +279,8 @@ func TestClient_GetComicCharacters(t *testing.T) {
 			t.Fatal("error is nil")
 		}
 
-		ae, ok := err.(*APIError)
+		var ae *APIError
+		ok := errors.As(err, &ae)
 
 		if !ok {
 			t.Fatalf("got error type %T, want %T", ae, err)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/md5"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -12,6 +13,10 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/loivis/marvel-comics-api-data-loader/maco"
 )
 
 // Client contains info for requests to marvel comics api.
@@ -20,27 +25,129 @@ type Client struct {
 	hc         *http.Client
 	privateKey string
 	publicKey  string
+	keys       *keyPool // keys in rotation, starting with privateKey and publicKey
+
+	cache     Cache      // optional cache for conditional requests
+	quota     *Quota     // optional daily budget of calls
+	scheduler *Scheduler // optional cap of requests in flight
+	validator *Validator // optional check of responses against spec
+}
+
+// Option configures optional features of Client.
+type Option func(*Client)
+
+// WithCache makes Client send conditional requests with etags of responses in cache,
+// serving cached responses when api answers 304 Not Modified.
+func WithCache(cache Cache) Option {
+	return func(c *Client) {
+		c.cache = cache
+	}
 }
 
+// TimeLayout is the layout of date fields in api responses, e.g. "modified".
+const TimeLayout = "2006-01-02T15:04:05-0700"
+
 // Params contains path info and query parameters for api requests.
 type Params struct {
 	typ     string // private field
 	id      *int   // avoid 0 when not set
 	subtype string
 
-	Limit   int
-	Offset  int
-	OrderBy string
+	Filter        Filter // typed filters of the listed resource, e.g. *ComicParams
+	Limit         int
+	ModifiedSince time.Time // only return resources modified since then
+	Offset        int
+	OrderBy       string
+
+	apikey  string          // overrides public key of client if set
+	ctx     context.Context // cancels the call along with the context passed to it if set
+	hash    string          // overrides hash computed from keys of client if set
+	ts      string          // overrides current time if set
+	timeout time.Duration   // deadline of the call if set
+}
+
+var _ maco.Params = (*Params)(nil)
+
+// SetApikey sets the public key sent instead of the one of client.
+func (p *Params) SetApikey(apikey string) {
+	p.apikey = apikey
+}
+
+// SetContext sets a context cancelling the call in addition to the one passed to it.
+func (p *Params) SetContext(ctx context.Context) {
+	p.ctx = ctx
+}
+
+// SetHash sets the hash sent instead of the one computed from keys of client.
+func (p *Params) SetHash(hash string) {
+	p.hash = hash
+}
+
+// SetTs sets the timestamp sent instead of current time.
+func (p *Params) SetTs(ts string) {
+	p.ts = ts
+}
+
+// SetTimeout sets the deadline of the call from when it starts.
+func (p *Params) SetTimeout(timeout time.Duration) {
+	p.timeout = timeout
+}
+
+// WithQuota makes Client count every call against quota before sending it.
+func WithQuota(quota *Quota) Option {
+	return func(c *Client) {
+		c.quota = quota
+	}
+}
+
+// WithValidator makes Client check every response against spec with validator.
+// Mismatches are logged and counted, but never fail a call.
+func WithValidator(validator *Validator) Option {
+	return func(c *Client) {
+		c.validator = validator
+	}
 }
 
 // NewClient returns a marvel Client.
-func NewClient(baseURL, privateKey, publicKey string) *Client {
-	return &Client{
+func NewClient(baseURL, privateKey, publicKey string, opts ...Option) *Client {
+	c := &Client{
 		baseURL:    strings.Trim(baseURL, "/"),
 		hc:         &http.Client{Timeout: 150 * time.Second},
 		privateKey: privateKey,
 		publicKey:  publicKey,
+		keys:       &keyPool{keys: []Key{{Private: privateKey, Public: publicKey}}},
+	}
+
+	for _, opt := range opts {
+		opt(c)
 	}
+
+	return c
+}
+
+// Remaining returns calls left today within quota of keys in rotation,
+// or 0 if every key is out of rotation.
+// ok is false if no quota is configured or usage could not be loaded.
+func (c *Client) Remaining() (remaining int, ok bool) {
+	keys, err := c.keys.available()
+	if err != nil {
+		return 0, true
+	}
+
+	if c.quota == nil {
+		return 0, false
+	}
+
+	for _, key := range keys {
+		n, err := c.quota.Remaining(key.Public)
+		if err != nil {
+			return 0, false
+		}
+
+		remaining += n
+	}
+
+	return remaining, true
 }
 
 // GetCount returns count for the given type.
@@ -64,6 +171,35 @@ func (c *Client) GetCount(ctx context.Context, typ string) (int, error) {
 	return data.Data.Total, nil
 }
 
+// GetRelated returns a page of subtype related to the given type and id with given Params,
+// e.g. comics of a character.
+func (c *Client) GetRelated(ctx context.Context, typ string, id int, subtype string, params *Params) (ListPage, error) {
+	if params == nil {
+		return nil, errors.New("params is nil")
+	}
+
+	params.typ = typ
+	params.id = &id
+	params.subtype = subtype
+
+	switch subtype {
+	case maco.TypeCharacters:
+		return c.getCharacters(ctx, params)
+	case maco.TypeComics:
+		return c.getComics(ctx, params)
+	case maco.TypeCreators:
+		return c.getCreators(ctx, params)
+	case maco.TypeEvents:
+		return c.getEvents(ctx, params)
+	case maco.TypeSeries:
+		return c.getSeries(ctx, params)
+	case maco.TypeStories:
+		return c.getStories(ctx, params)
+	}
+
+	return nil, fmt.Errorf("unsupported subtype: %s", subtype)
+}
+
 // get returns api response as slice of byte with given path and request params.
 func (c *Client) get(ctx context.Context, params *Params) ([]byte, error) {
 	path, err := pathFromParams(params)
@@ -71,41 +207,175 @@ func (c *Client) get(ctx context.Context, params *Params) ([]byte, error) {
 		return nil, err
 	}
 
+	err = checkParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := callContext(ctx, params)
+	defer cancel()
+
+	// waiting for a key, quota or slot does not count against the timeout of the call
+	apiKey, err := c.acquireKey(ctx)
+	if err != nil {
+		return nil, contextError(ctx, path, err)
+	}
+
+	release, err := c.scheduler.Acquire(ctx)
+	if err != nil {
+		return nil, contextError(ctx, path, err)
+	}
+	defer release()
+
+	waitCtx := ctx
+	ctx, cancelTimeout := callTimeout(ctx, params)
+	defer cancelTimeout()
+
 	u := fmt.Sprintf("%s/%s", c.baseURL, path)
 
 	req, err := http.NewRequest(http.MethodGet, u, nil)
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
+
+	ts := params.ts
+	if ts == "" {
+		ts = fmt.Sprintf("%d", time.Now().Unix())
+	}
+	query := c.buildQuery(req.URL.Query(), params, apiKey, ts)
+	req.URL.RawQuery = query.Encode()
+
+	var key string
+	var cached []byte
+	if c.cache != nil {
+		key = cacheKey(path, query)
+		if etag, body, ok := c.cache.Get(key); ok {
+			req.Header.Set("If-None-Match", etag)
+			cached = body
+		}
+	}
 
-	ts := fmt.Sprintf("%d", time.Now().Unix())
-	req.URL.RawQuery = c.buildQuery(req.URL.Query(), params, ts).Encode()
+	started := time.Now()
 
 	resp, err := c.hc.Do(req)
 	if err != nil {
-		return nil, err
+		c.scheduler.Observe(time.Since(started), overloaded(0, err))
+		return nil, contextError(ctx, path, err)
 	}
 	defer resp.Body.Close()
 
 	b, err := ioutil.ReadAll(resp.Body)
+	c.scheduler.Observe(time.Since(started), overloaded(resp.StatusCode, err))
 	if err != nil {
-		return nil, err
+		return nil, contextError(ctx, path, err)
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		return cached, nil
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusTooManyRequests {
+		if c.keys.bench(apiKey, resp.StatusCode) {
+			// every retry benches one more key, until acquireKey finds none left
+			release()
+			return c.get(waitCtx, params)
+		}
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, &APIError{Code: resp.StatusCode, Message: string(b)}
+		return nil, newAPIError(resp.StatusCode, b)
+	}
+
+	if c.cache != nil {
+		c.cacheResponse(key, b)
+	}
+
+	if c.validator != nil {
+		c.validate(params, b)
 	}
 
 	return b, nil
 }
 
-func (c *Client) buildQuery(in url.Values, params *Params, ts string) url.Values {
+// callContext returns ctx cancelled also by the context set in params.
+func callContext(ctx context.Context, params *Params) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	if params.ctx != nil {
+		go func() {
+			select {
+			case <-params.ctx.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	return ctx, cancel
+}
+
+// callTimeout returns ctx cancelled after the timeout set in params, if any.
+func callTimeout(ctx context.Context, params *Params) (context.Context, context.CancelFunc) {
+	if params.timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, params.timeout)
+}
+
+// contextError returns *ContextError instead of err if the call stopped because ctx is done.
+func contextError(ctx context.Context, path string, err error) error {
+	if ctx.Err() == nil {
+		return err
+	}
+
+	return &ContextError{Path: path, Err: ctx.Err()}
+}
+
+// cacheResponse caches body with its etag, if any.
+// Failing to cache only costs a full response next time, so errors are logged rather than returned.
+func (c *Client) cacheResponse(key string, body []byte) {
+	var data struct {
+		Etag string
+	}
+
+	if err := json.Unmarshal(body, &data); err != nil || data.Etag == "" {
+		return
+	}
+
+	if err := c.cache.Set(key, data.Etag, body); err != nil {
+		log.Warn().Str("key", key).Msgf("error caching response: %v", err)
+	}
+}
+
+// validate checks body against spec, logging instead of returning errors as validation is only informative.
+func (c *Client) validate(params *Params, body []byte) {
+	typ := params.subtype
+	if typ == "" {
+		typ = params.typ
+	}
+
+	if err := c.validator.Validate(typ, body); err != nil {
+		log.Warn().Str("type", typ).Msgf("error validating response: %v", err)
+	}
+}
+
+func (c *Client) buildQuery(in url.Values, params *Params, key Key, ts string) url.Values {
 	out := url.Values{
-		"apikey": {c.publicKey},
-		"hash":   {fmt.Sprintf("%x", md5.Sum([]byte(ts+c.privateKey+c.publicKey)))},
+		"apikey": {key.Public},
+		"hash":   {fmt.Sprintf("%x", md5.Sum([]byte(ts+key.Private+key.Public)))},
 		"ts":     {ts},
 	}
 
+	if params != nil && params.apikey != "" {
+		out["apikey"] = []string{params.apikey}
+	}
+
+	if params != nil && params.hash != "" {
+		out["hash"] = []string{params.hash}
+	}
+
 	for k, v := range in {
 		out[k] = append(out[k], v...)
 	}
@@ -122,6 +392,14 @@ func (c *Client) buildQuery(in url.Values, params *Params, ts string) url.Values
 		if params.OrderBy != "" {
 			out["orderBy"] = []string{params.OrderBy}
 		}
+
+		if !params.ModifiedSince.IsZero() {
+			out["modifiedSince"] = []string{params.ModifiedSince.Format(TimeLayout)}
+		}
+
+		if params.Filter != nil {
+			params.Filter.encode(out)
+		}
 	}
 
 	return out
@@ -3,11 +3,13 @@ package marvel
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestNew(t *testing.T) {
@@ -98,7 +100,8 @@ func TestClient_Get(t *testing.T) {
 			t.Fatal("error is nil")
 		}
 
-		ae, ok := err.(*APIError)
+		var ae *APIError
+		ok := errors.As(err, &ae)
 
 		if !ok {
 			t.Fatalf("got error type %T, want %T", ae, err)
@@ -132,6 +135,62 @@ func TestClient_Get(t *testing.T) {
 			t.Errorf("got error %q, want %q", got, want)
 		}
 	})
+
+	t.Run("ContextError", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+		defer ts.Close()
+
+		c := NewClient(ts.URL, "", "")
+
+		cancelled := func() context.Context {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			return ctx
+		}
+
+		for _, tc := range []struct {
+			desc    string
+			ctx     context.Context
+			params  func(*Params)
+			timeout bool
+		}{
+			{
+				desc: "Cancel",
+				ctx:  cancelled(),
+			},
+			{
+				desc:   "SetContext",
+				ctx:    context.Background(),
+				params: func(p *Params) { p.SetContext(cancelled()) },
+			},
+			{
+				desc:    "SetTimeout",
+				ctx:     context.Background(),
+				params:  func(p *Params) { p.SetTimeout(10 * time.Millisecond) },
+				timeout: true,
+			},
+		} {
+			t.Run(tc.desc, func(t *testing.T) {
+				params := &Params{typ: "foo"}
+				if tc.params != nil {
+					tc.params(params)
+				}
+
+				_, err := c.get(tc.ctx, params)
+
+				ce, ok := err.(*ContextError)
+				if !ok {
+					t.Fatalf("got error type %T, want %T", err, ce)
+				}
+
+				if got, want := ce.Timeout(), tc.timeout; got != want {
+					t.Errorf("got timeout %t, want %t", got, want)
+				}
+			})
+		}
+	})
 }
 
 func TestClient_GetCount(t *testing.T) {
@@ -184,7 +243,8 @@ func TestClient_GetCount(t *testing.T) {
 			t.Fatal("error is nil")
 		}
 
-		ae, ok := err.(*APIError)
+		var ae *APIError
+		ok := errors.As(err, &ae)
 
 		if !ok {
 			t.Fatalf("got error type %T, want %T", ae, err)
@@ -218,6 +278,47 @@ func TestClient_GetCount(t *testing.T) {
 	})
 }
 
+func TestClient_GetRelated(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var gotRequest *http.Request
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotRequest = r
+			w.Write([]byte(`{"data":{"total":2,"results":[{"id":1},{"id":2}]}}`))
+		}))
+		defer ts.Close()
+
+		c := NewClient(ts.URL, "", "public")
+
+		page, err := c.GetRelated(context.Background(), "characters", 123, "comics", &Params{Limit: 10})
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if got, want := gotRequest.URL.Path, "/characters/123/comics"; got != want {
+			t.Errorf("got request path %q, want %q", got, want)
+		}
+
+		if _, ok := page.(*ComicPage); !ok {
+			t.Fatalf("got page type %T, want %T", page, &ComicPage{})
+		}
+
+		if got, want := page.IDs(), []int{1, 2}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got ids %v, want %v", got, want)
+		}
+	})
+
+	t.Run("UnsupportedSubtype", func(t *testing.T) {
+		c := NewClient("", "", "")
+
+		_, err := c.GetRelated(context.Background(), "characters", 123, "foo", &Params{})
+
+		if err == nil {
+			t.Fatal("error is nil")
+		}
+	})
+}
+
 func TestClient_BuildQuery(t *testing.T) {
 	for _, tc := range []struct {
 		desc   string
@@ -236,6 +337,40 @@ func TestClient_BuildQuery(t *testing.T) {
 				"ts":     {"12345"},
 			},
 		},
+		{
+			desc:   "ModifiedSince",
+			params: &Params{ModifiedSince: time.Date(2019, 5, 1, 8, 30, 0, 0, time.UTC)},
+			want: url.Values{
+				"apikey":        {"public"},
+				"foo":           {"bar", "baz"},
+				"hash":          {"fc1af084cf4c71dd2d6ddbd14e5b3e32"},
+				"modifiedSince": {"2019-05-01T08:30:00+0000"},
+				"ts":            {"12345"},
+			},
+		},
+		{
+			desc:   "Filter",
+			params: &Params{Limit: 1, Filter: &ComicParams{Format: "comic", Characters: []int{1, 2}}},
+			want: url.Values{
+				"apikey":     {"public"},
+				"characters": {"1,2"},
+				"foo":        {"bar", "baz"},
+				"format":     {"comic"},
+				"hash":       {"fc1af084cf4c71dd2d6ddbd14e5b3e32"},
+				"limit":      {"1"},
+				"ts":         {"12345"},
+			},
+		},
+		{
+			desc:   "Overrides",
+			params: &Params{apikey: "other", hash: "foo"},
+			want: url.Values{
+				"apikey": {"other"},
+				"foo":    {"bar", "baz"},
+				"hash":   {"foo"},
+				"ts":     {"12345"},
+			},
+		},
 		{
 			desc:   "NilParams",
 			params: nil,
@@ -250,7 +385,7 @@ func TestClient_BuildQuery(t *testing.T) {
 		t.Run(tc.desc, func(t *testing.T) {
 			c := NewClient("", "", "public")
 
-			got := c.buildQuery(url.Values{"foo": {"bar", "baz"}}, tc.params, "12345")
+			got := c.buildQuery(url.Values{"foo": {"bar", "baz"}}, tc.params, Key{Public: "public"}, "12345")
 
 			if !reflect.DeepEqual(got, tc.want) {
 				t.Errorf("got query %v, want %v", got, tc.want)
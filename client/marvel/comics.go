@@ -33,8 +33,8 @@ func (c *Client) GetComic(ctx context.Context, id int) (*Comic, error) {
 	return data.Data.Results[0], nil
 }
 
-// GetComics returns list of comic with given Params.
-func (c *Client) GetComics(ctx context.Context, params *Params) ([]*Comic, error) {
+// GetComics returns a page of comic with given Params.
+func (c *Client) GetComics(ctx context.Context, params *Params) (*ComicPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -44,8 +44,8 @@ func (c *Client) GetComics(ctx context.Context, params *Params) ([]*Comic, error
 	return c.getComics(ctx, params)
 }
 
-// GetCharacterComics returns list of comics filtered by character ID.
-func (c *Client) GetCharacterComics(ctx context.Context, id int, params *Params) ([]*Comic, error) {
+// GetCharacterComics returns a page of comics filtered by character ID.
+func (c *Client) GetCharacterComics(ctx context.Context, id int, params *Params) (*ComicPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -57,8 +57,8 @@ func (c *Client) GetCharacterComics(ctx context.Context, id int, params *Params)
 	return c.getComics(ctx, params)
 }
 
-// GetCreatorComics returns list of comics filtered by creator ID.
-func (c *Client) GetCreatorComics(ctx context.Context, id int, params *Params) ([]*Comic, error) {
+// GetCreatorComics returns a page of comics filtered by creator ID.
+func (c *Client) GetCreatorComics(ctx context.Context, id int, params *Params) (*ComicPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -70,8 +70,8 @@ func (c *Client) GetCreatorComics(ctx context.Context, id int, params *Params) (
 	return c.getComics(ctx, params)
 }
 
-// GetEventComics returns list of comics filtered by event ID.
-func (c *Client) GetEventComics(ctx context.Context, id int, params *Params) ([]*Comic, error) {
+// GetEventComics returns a page of comics filtered by event ID.
+func (c *Client) GetEventComics(ctx context.Context, id int, params *Params) (*ComicPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -83,8 +83,8 @@ func (c *Client) GetEventComics(ctx context.Context, id int, params *Params) ([]
 	return c.getComics(ctx, params)
 }
 
-// GetSeriesComics returns list of comics filtered by series ID.
-func (c *Client) GetSeriesComics(ctx context.Context, id int, params *Params) ([]*Comic, error) {
+// GetSeriesComics returns a page of comics filtered by series ID.
+func (c *Client) GetSeriesComics(ctx context.Context, id int, params *Params) (*ComicPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -96,8 +96,8 @@ func (c *Client) GetSeriesComics(ctx context.Context, id int, params *Params) ([
 	return c.getComics(ctx, params)
 }
 
-// GetStoryComics returns list of comics filtered by story ID.
-func (c *Client) GetStoryComics(ctx context.Context, id int, params *Params) ([]*Comic, error) {
+// GetStoryComics returns a page of comics filtered by story ID.
+func (c *Client) GetStoryComics(ctx context.Context, id int, params *Params) (*ComicPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -109,24 +109,20 @@ func (c *Client) GetStoryComics(ctx context.Context, id int, params *Params) ([]
 	return c.getComics(ctx, params)
 }
 
-// getComics returns a list of comic with given Params.
+// getComics returns a page of comic with given Params.
 // If Params.subTyp is set, it returns comics filtered by Params.Type and Params.ID.
-func (c *Client) getComics(ctx context.Context, params *Params) ([]*Comic, error) {
+func (c *Client) getComics(ctx context.Context, params *Params) (*ComicPage, error) {
 	resp, err := c.get(ctx, params)
 	if err != nil {
 		return nil, err
 	}
 
-	var data struct {
-		Data struct {
-			Results []*Comic
-		}
-	}
+	page := &ComicPage{}
 
-	err = json.Unmarshal(resp, &data)
+	err = decodePage(resp, &page.Page, &page.Results)
 	if err != nil {
 		return nil, err
 	}
 
-	return data.Data.Results, nil
+	return page, nil
 }
@@ -33,8 +33,8 @@ func (c *Client) GetCreator(ctx context.Context, id int) (*Creator, error) {
 	return data.Data.Results[0], nil
 }
 
-// GetCreators returns list of creator with given Params.
-func (c *Client) GetCreators(ctx context.Context, params *Params) ([]*Creator, error) {
+// GetCreators returns a page of creator with given Params.
+func (c *Client) GetCreators(ctx context.Context, params *Params) (*CreatorPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -44,8 +44,8 @@ func (c *Client) GetCreators(ctx context.Context, params *Params) ([]*Creator, e
 	return c.getCreators(ctx, params)
 }
 
-// GetComicCreators returns list of creators filtered by comic ID.
-func (c *Client) GetComicCreators(ctx context.Context, id int, params *Params) ([]*Creator, error) {
+// GetComicCreators returns a page of creators filtered by comic ID.
+func (c *Client) GetComicCreators(ctx context.Context, id int, params *Params) (*CreatorPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -57,8 +57,8 @@ func (c *Client) GetComicCreators(ctx context.Context, id int, params *Params) (
 	return c.getCreators(ctx, params)
 }
 
-// GetEventCreators returns list of creators filtered by event ID.
-func (c *Client) GetEventCreators(ctx context.Context, id int, params *Params) ([]*Creator, error) {
+// GetEventCreators returns a page of creators filtered by event ID.
+func (c *Client) GetEventCreators(ctx context.Context, id int, params *Params) (*CreatorPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -70,8 +70,8 @@ func (c *Client) GetEventCreators(ctx context.Context, id int, params *Params) (
 	return c.getCreators(ctx, params)
 }
 
-// GetSeriesCreators returns list of creators filtered by series ID.
-func (c *Client) GetSeriesCreators(ctx context.Context, id int, params *Params) ([]*Creator, error) {
+// GetSeriesCreators returns a page of creators filtered by series ID.
+func (c *Client) GetSeriesCreators(ctx context.Context, id int, params *Params) (*CreatorPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -83,8 +83,8 @@ func (c *Client) GetSeriesCreators(ctx context.Context, id int, params *Params)
 	return c.getCreators(ctx, params)
 }
 
-// GetStoryCreators returns list of creators filtered by story ID.
-func (c *Client) GetStoryCreators(ctx context.Context, id int, params *Params) ([]*Creator, error) {
+// GetStoryCreators returns a page of creators filtered by story ID.
+func (c *Client) GetStoryCreators(ctx context.Context, id int, params *Params) (*CreatorPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -96,24 +96,20 @@ func (c *Client) GetStoryCreators(ctx context.Context, id int, params *Params) (
 	return c.getCreators(ctx, params)
 }
 
-// getCreators returns a list of creator with given Params.
+// getCreators returns a page of creator with given Params.
 // If Params.subTyp is set, it returns creators filtered by Params.Type and Params.ID.
-func (c *Client) getCreators(ctx context.Context, params *Params) ([]*Creator, error) {
+func (c *Client) getCreators(ctx context.Context, params *Params) (*CreatorPage, error) {
 	resp, err := c.get(ctx, params)
 	if err != nil {
 		return nil, err
 	}
 
-	var data struct {
-		Data struct {
-			Results []*Creator
-		}
-	}
+	page := &CreatorPage{}
 
-	err = json.Unmarshal(resp, &data)
+	err = decodePage(resp, &page.Page, &page.Results)
 	if err != nil {
 		return nil, err
 	}
 
-	return data.Data.Results, nil
+	return page, nil
 }
@@ -1,19 +1,105 @@
 package marvel
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
+	"time"
 )
 
+// APIError is an error response of api.
+// Responses of known errors are returned as one of *AuthError, *ParamError, *NotFoundError,
+// *RateLimitError or *ServerError, all unwrapping to *APIError.
 type APIError struct {
-	Code    int
-	Message string
+	Code    int    // http status
+	Status  string // error code in body if not numeric, e.g. InvalidCredentials
+	Message string // message or status in body, the whole body if not json
 }
 
 func (ae *APIError) Error() string {
+	if ae.Status != "" {
+		return fmt.Sprintf("status %d %s: %s", ae.Code, ae.Status, ae.Message)
+	}
+
 	return fmt.Sprintf("status %d: %s", ae.Code, ae.Message)
 }
 
+// Retryable reports whether the call may succeed if sent again.
+func (ae *APIError) Retryable() bool {
+	return false
+}
+
+// AuthError is returned when api refuses the key, hash or timestamp of a call.
+type AuthError struct{ *APIError }
+
+func (e *AuthError) Unwrap() error { return e.APIError }
+
+// ParamError is returned when a parameter of a call is invalid or missing.
+type ParamError struct{ *APIError }
+
+func (e *ParamError) Unwrap() error { return e.APIError }
+
+// NotFoundError is returned when the requested resource does not exist.
+type NotFoundError struct{ *APIError }
+
+func (e *NotFoundError) Unwrap() error { return e.APIError }
+
+// RateLimitError is returned when the daily limit of the key is exceeded.
+// It is not retryable as the limit resets only at utc midnight.
+type RateLimitError struct{ *APIError }
+
+func (e *RateLimitError) Unwrap() error { return e.APIError }
+
+// ServerError is returned on failures of api itself.
+type ServerError struct{ *APIError }
+
+func (e *ServerError) Unwrap() error { return e.APIError }
+
+// Retryable reports whether the call may succeed if sent again.
+func (e *ServerError) Retryable() bool { return true }
+
+// newAPIError returns the error of a response with status code and body,
+// parsing the error envelope of api, e.g. {"code":"InvalidCredentials","message":"..."} or {"code":409,"status":"..."}.
+func newAPIError(code int, body []byte) error {
+	ae := &APIError{Code: code, Message: string(body)}
+
+	var envelope struct {
+		Code    interface{}
+		Message string
+		Status  string
+	}
+
+	if err := json.Unmarshal(body, &envelope); err == nil {
+		if s, ok := envelope.Code.(string); ok {
+			ae.Status = s
+		}
+
+		switch {
+		case envelope.Message != "":
+			ae.Message = envelope.Message
+		case envelope.Status != "":
+			ae.Message = envelope.Status
+		}
+	}
+
+	switch {
+	case code == http.StatusUnauthorized, code == http.StatusForbidden, ae.Status == "InvalidCredentials", ae.Status == "InvalidReferer":
+		return &AuthError{ae}
+	case code == http.StatusBadRequest, code == http.StatusConflict:
+		return &ParamError{ae}
+	case code == http.StatusNotFound:
+		return &NotFoundError{ae}
+	case code == http.StatusTooManyRequests, ae.Status == "RequestThrottled":
+		return &RateLimitError{ae}
+	case code >= http.StatusInternalServerError:
+		return &ServerError{ae}
+	}
+
+	return ae
+}
+
 type PathError struct {
 	Missing []string
 }
@@ -21,3 +107,58 @@ type PathError struct {
 func (pe *PathError) Error() string {
 	return fmt.Sprintf("missing in path: %s", strings.Join(pe.Missing, ", "))
 }
+
+type FilterError struct {
+	Name   string
+	Value  string
+	Reason string
+}
+
+func (fe *FilterError) Error() string {
+	return fmt.Sprintf("invalid %s %q: %s", fe.Name, fe.Value, fe.Reason)
+}
+
+type QuotaError struct {
+	Key    string
+	Used   int
+	Budget int
+	Reset  time.Time
+}
+
+func (qe *QuotaError) Error() string {
+	return fmt.Sprintf("used %d of %d daily calls for key %s, resets at %s", qe.Used, qe.Budget, qe.Key, qe.Reset.Format(time.RFC3339))
+}
+
+// KeyError is returned when every key is out of rotation after being refused by api.
+type KeyError struct {
+	Key   string // key back in rotation earliest
+	Reset time.Time
+}
+
+func (ke *KeyError) Error() string {
+	return fmt.Sprintf("all keys out of rotation, key %s back at %s", ke.Key, ke.Reset.Format(time.RFC3339))
+}
+
+// ContextError is returned when a call stops as its context is cancelled or past its deadline.
+type ContextError struct {
+	Path string
+	Err  error // context.Canceled or context.DeadlineExceeded
+}
+
+func (ce *ContextError) Error() string {
+	return fmt.Sprintf("call to %s stopped: %v", ce.Path, ce.Err)
+}
+
+func (ce *ContextError) Unwrap() error {
+	return ce.Err
+}
+
+// Timeout reports whether the call stopped at its deadline rather than being cancelled.
+func (ce *ContextError) Timeout() bool {
+	return ce.Err == context.DeadlineExceeded
+}
+
+// Retryable reports whether the call may succeed if sent again, i.e. it stopped at its own deadline.
+func (ce *ContextError) Retryable() bool {
+	return ce.Timeout()
+}
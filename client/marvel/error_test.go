@@ -1,8 +1,13 @@
 package marvel
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestAPIError_Error(t *testing.T) {
@@ -24,3 +29,131 @@ func TestPathError_Error(t *testing.T) {
 		}
 	})
 }
+
+func TestFilterError_Error(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		fe := &FilterError{Name: "format", Value: "foo", Reason: "bar"}
+
+		if got, want := fe.Error(), `invalid format "foo": bar`; got != want {
+			t.Errorf("got error %q, want %q", got, want)
+		}
+	})
+}
+
+func TestQuotaError_Error(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		qe := &QuotaError{Key: "foo", Used: 3, Budget: 3, Reset: time.Date(2019, 5, 2, 0, 0, 0, 0, time.UTC)}
+
+		if got, want := qe.Error(), "used 3 of 3 daily calls for key foo, resets at 2019-05-02T00:00:00Z"; got != want {
+			t.Errorf("got error %q, want %q", got, want)
+		}
+	})
+}
+
+func TestContextError_Error(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ce := &ContextError{Path: "comics", Err: context.DeadlineExceeded}
+
+		if got, want := ce.Error(), "call to comics stopped: context deadline exceeded"; got != want {
+			t.Errorf("got error %q, want %q", got, want)
+		}
+	})
+}
+
+func TestNewAPIError(t *testing.T) {
+	for _, tc := range []struct {
+		desc      string
+		code      int
+		body      string
+		want      error
+		status    string
+		message   string
+		retryable bool
+	}{
+		{
+			desc:    "Auth",
+			code:    http.StatusUnauthorized,
+			body:    `{"code":"InvalidCredentials","message":"That hash, timestamp and key combination is invalid."}`,
+			want:    &AuthError{},
+			status:  "InvalidCredentials",
+			message: "That hash, timestamp and key combination is invalid.",
+		},
+		{
+			desc:    "Param",
+			code:    http.StatusConflict,
+			body:    `{"code":409,"status":"You may not request more than 100 items."}`,
+			want:    &ParamError{},
+			message: "You may not request more than 100 items.",
+		},
+		{
+			desc:    "NotFound",
+			code:    http.StatusNotFound,
+			body:    `{"code":404,"status":"We couldn't find that character"}`,
+			want:    &NotFoundError{},
+			message: "We couldn't find that character",
+		},
+		{
+			desc:    "RateLimit",
+			code:    http.StatusTooManyRequests,
+			body:    `{"code":"RequestThrottled","message":"You have exceeded your rate limit.  Please try again later."}`,
+			want:    &RateLimitError{},
+			status:  "RequestThrottled",
+			message: "You have exceeded your rate limit.  Please try again later.",
+		},
+		{
+			desc:      "Server",
+			code:      http.StatusBadGateway,
+			body:      `not json`,
+			want:      &ServerError{},
+			message:   "not json",
+			retryable: true,
+		},
+		{
+			desc:    "Other",
+			code:    http.StatusMethodNotAllowed,
+			body:    `{"code":405,"status":"Method Not Allowed"}`,
+			want:    &APIError{},
+			message: "Method Not Allowed",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := newAPIError(tc.code, []byte(tc.body))
+
+			if got, want := reflect.TypeOf(err), reflect.TypeOf(tc.want); got != want {
+				t.Fatalf("got error type %v, want %v", got, want)
+			}
+
+			wrapped := fmt.Errorf("foo: %w", err)
+
+			var ae *APIError
+			if !errors.As(wrapped, &ae) {
+				t.Fatalf("got error %T not as %T", wrapped, ae)
+			}
+
+			if got, want := ae.Code, tc.code; got != want {
+				t.Errorf("got code %d, want %d", got, want)
+			}
+
+			if got, want := ae.Status, tc.status; got != want {
+				t.Errorf("got status %q, want %q", got, want)
+			}
+
+			if got, want := ae.Message, tc.message; got != want {
+				t.Errorf("got message %q, want %q", got, want)
+			}
+
+			if !errors.Is(wrapped, err) {
+				t.Errorf("got error %v not wrapping %v", wrapped, err)
+			}
+
+			var r interface{ Retryable() bool }
+			if !errors.As(wrapped, &r) {
+				t.Fatalf("got error %T not retryable", wrapped)
+			}
+
+			if got, want := r.Retryable(), tc.retryable; got != want {
+				t.Errorf("got retryable %t, want %t", got, want)
+			}
+		})
+	}
+}
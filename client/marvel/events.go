@@ -33,8 +33,8 @@ func (c *Client) GetEvent(ctx context.Context, id int) (*Event, error) {
 	return data.Data.Results[0], nil
 }
 
-// GetEvents returns list of event with given Params.
-func (c *Client) GetEvents(ctx context.Context, params *Params) ([]*Event, error) {
+// GetEvents returns a page of event with given Params.
+func (c *Client) GetEvents(ctx context.Context, params *Params) (*EventPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -44,8 +44,8 @@ func (c *Client) GetEvents(ctx context.Context, params *Params) ([]*Event, error
 	return c.getEvents(ctx, params)
 }
 
-// GetCharacterEvents returns list of events filtered by character ID.
-func (c *Client) GetCharacterEvents(ctx context.Context, id int, params *Params) ([]*Event, error) {
+// GetCharacterEvents returns a page of events filtered by character ID.
+func (c *Client) GetCharacterEvents(ctx context.Context, id int, params *Params) (*EventPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -57,8 +57,8 @@ func (c *Client) GetCharacterEvents(ctx context.Context, id int, params *Params)
 	return c.getEvents(ctx, params)
 }
 
-// GetComicEvents returns list of events filtered by comic ID.
-func (c *Client) GetComicEvents(ctx context.Context, id int, params *Params) ([]*Event, error) {
+// GetComicEvents returns a page of events filtered by comic ID.
+func (c *Client) GetComicEvents(ctx context.Context, id int, params *Params) (*EventPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -70,8 +70,8 @@ func (c *Client) GetComicEvents(ctx context.Context, id int, params *Params) ([]
 	return c.getEvents(ctx, params)
 }
 
-// GetCreatorEvents returns list of events filtered by creator ID.
-func (c *Client) GetCreatorEvents(ctx context.Context, id int, params *Params) ([]*Event, error) {
+// GetCreatorEvents returns a page of events filtered by creator ID.
+func (c *Client) GetCreatorEvents(ctx context.Context, id int, params *Params) (*EventPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -83,8 +83,8 @@ func (c *Client) GetCreatorEvents(ctx context.Context, id int, params *Params) (
 	return c.getEvents(ctx, params)
 }
 
-// GetSeriesEvents returns list of events filtered by series ID.
-func (c *Client) GetSeriesEvents(ctx context.Context, id int, params *Params) ([]*Event, error) {
+// GetSeriesEvents returns a page of events filtered by series ID.
+func (c *Client) GetSeriesEvents(ctx context.Context, id int, params *Params) (*EventPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -96,8 +96,8 @@ func (c *Client) GetSeriesEvents(ctx context.Context, id int, params *Params) ([
 	return c.getEvents(ctx, params)
 }
 
-// GetStoryEvents returns list of events filtered by story ID.
-func (c *Client) GetStoryEvents(ctx context.Context, id int, params *Params) ([]*Event, error) {
+// GetStoryEvents returns a page of events filtered by story ID.
+func (c *Client) GetStoryEvents(ctx context.Context, id int, params *Params) (*EventPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -109,24 +109,20 @@ func (c *Client) GetStoryEvents(ctx context.Context, id int, params *Params) ([]
 	return c.getEvents(ctx, params)
 }
 
-// getEvents returns a list of event with given Params.
+// getEvents returns a page of event with given Params.
 // If Params.subTyp is set, it returns events filtered by Params.Type and Params.ID.
-func (c *Client) getEvents(ctx context.Context, params *Params) ([]*Event, error) {
+func (c *Client) getEvents(ctx context.Context, params *Params) (*EventPage, error) {
 	resp, err := c.get(ctx, params)
 	if err != nil {
 		return nil, err
 	}
 
-	var data struct {
-		Data struct {
-			Results []*Event
-		}
-	}
+	page := &EventPage{}
 
-	err = json.Unmarshal(resp, &data)
+	err = decodePage(resp, &page.Page, &page.Results)
 	if err != nil {
 		return nil, err
 	}
 
-	return data.Data.Results, nil
+	return page, nil
 }
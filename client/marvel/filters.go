@@ -0,0 +1,384 @@
+package marvel
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter narrows down list requests of one endpoint family, e.g. ComicParams for all lists of comics.
+type Filter interface {
+	// resource returns the type of resources the filter applies to.
+	resource() string
+	// validate checks filter values against allowed values in api spec.
+	validate() error
+	// encode adds non-zero filter values to query.
+	encode(q url.Values)
+}
+
+// CharacterParams contains filters of character lists.
+type CharacterParams struct {
+	Comics         []int
+	Events         []int
+	Name           string
+	NameStartsWith string
+	Series         []int
+	Stories        []int
+}
+
+func (cp *CharacterParams) resource() string { return "characters" }
+
+func (cp *CharacterParams) validate() error { return nil }
+
+func (cp *CharacterParams) encode(q url.Values) {
+	setInts(q, "comics", cp.Comics)
+	setInts(q, "events", cp.Events)
+	setString(q, "name", cp.Name)
+	setString(q, "nameStartsWith", cp.NameStartsWith)
+	setInts(q, "series", cp.Series)
+	setInts(q, "stories", cp.Stories)
+}
+
+// ComicParams contains filters of comic lists.
+type ComicParams struct {
+	Characters        []int
+	Collaborators     []int // comics in which the given creators worked together
+	Creators          []int
+	DateDescriptor    string
+	DateRange         []time.Time // inclusive range of exactly two dates
+	DiamondCode       string
+	DigitalID         int
+	EAN               string
+	Events            []int
+	Format            string
+	FormatType        string
+	HasDigitalIssue   bool
+	ISBN              string
+	ISSN              string
+	IssueNumber       int
+	NoVariants        bool
+	Series            []int
+	SharedAppearances []int // comics in which the given characters appear together
+	StartYear         int
+	Stories           []int
+	Title             string
+	TitleStartsWith   string
+	UPC               string
+}
+
+func (cp *ComicParams) resource() string { return "comics" }
+
+func (cp *ComicParams) validate() error {
+	if err := checkEnum("dateDescriptor", cp.DateDescriptor, dateDescriptors); err != nil {
+		return err
+	}
+
+	if err := checkEnum("format", cp.Format, formats); err != nil {
+		return err
+	}
+
+	if err := checkEnum("formatType", cp.FormatType, formatTypes); err != nil {
+		return err
+	}
+
+	if len(cp.DateRange) != 0 && len(cp.DateRange) != 2 {
+		return &FilterError{Name: "dateRange", Value: fmt.Sprint(cp.DateRange), Reason: "want exactly two dates"}
+	}
+
+	return nil
+}
+
+func (cp *ComicParams) encode(q url.Values) {
+	setInts(q, "characters", cp.Characters)
+	setInts(q, "collaborators", cp.Collaborators)
+	setInts(q, "creators", cp.Creators)
+	setString(q, "dateDescriptor", cp.DateDescriptor)
+	if len(cp.DateRange) == 2 {
+		q.Set("dateRange", cp.DateRange[0].Format("2006-01-02")+","+cp.DateRange[1].Format("2006-01-02"))
+	}
+	setString(q, "diamondCode", cp.DiamondCode)
+	setInt(q, "digitalId", cp.DigitalID)
+	setString(q, "ean", cp.EAN)
+	setInts(q, "events", cp.Events)
+	setString(q, "format", cp.Format)
+	setString(q, "formatType", cp.FormatType)
+	setBool(q, "hasDigitalIssue", cp.HasDigitalIssue)
+	setString(q, "isbn", cp.ISBN)
+	setString(q, "issn", cp.ISSN)
+	setInt(q, "issueNumber", cp.IssueNumber)
+	setBool(q, "noVariants", cp.NoVariants)
+	setInts(q, "series", cp.Series)
+	setInts(q, "sharedAppearances", cp.SharedAppearances)
+	setInt(q, "startYear", cp.StartYear)
+	setInts(q, "stories", cp.Stories)
+	setString(q, "title", cp.Title)
+	setString(q, "titleStartsWith", cp.TitleStartsWith)
+	setString(q, "upc", cp.UPC)
+}
+
+// CreatorParams contains filters of creator lists.
+type CreatorParams struct {
+	Comics               []int
+	Events               []int
+	FirstName            string
+	FirstNameStartsWith  string
+	LastName             string
+	LastNameStartsWith   string
+	MiddleName           string
+	MiddleNameStartsWith string
+	NameStartsWith       string
+	Series               []int
+	Stories              []int
+	Suffix               string
+}
+
+func (cp *CreatorParams) resource() string { return "creators" }
+
+func (cp *CreatorParams) validate() error { return nil }
+
+func (cp *CreatorParams) encode(q url.Values) {
+	setInts(q, "comics", cp.Comics)
+	setInts(q, "events", cp.Events)
+	setString(q, "firstName", cp.FirstName)
+	setString(q, "firstNameStartsWith", cp.FirstNameStartsWith)
+	setString(q, "lastName", cp.LastName)
+	setString(q, "lastNameStartsWith", cp.LastNameStartsWith)
+	setString(q, "middleName", cp.MiddleName)
+	setString(q, "middleNameStartsWith", cp.MiddleNameStartsWith)
+	setString(q, "nameStartsWith", cp.NameStartsWith)
+	setInts(q, "series", cp.Series)
+	setInts(q, "stories", cp.Stories)
+	setString(q, "suffix", cp.Suffix)
+}
+
+// EventParams contains filters of event lists.
+type EventParams struct {
+	Characters     []int
+	Comics         []int
+	Creators       []int
+	Name           string
+	NameStartsWith string
+	Series         []int
+	Stories        []int
+}
+
+func (ep *EventParams) resource() string { return "events" }
+
+func (ep *EventParams) validate() error { return nil }
+
+func (ep *EventParams) encode(q url.Values) {
+	setInts(q, "characters", ep.Characters)
+	setInts(q, "comics", ep.Comics)
+	setInts(q, "creators", ep.Creators)
+	setString(q, "name", ep.Name)
+	setString(q, "nameStartsWith", ep.NameStartsWith)
+	setInts(q, "series", ep.Series)
+	setInts(q, "stories", ep.Stories)
+}
+
+// SeriesParams contains filters of series lists.
+type SeriesParams struct {
+	Characters      []int
+	Comics          []int
+	Contains        []string // comic formats
+	Creators        []int
+	Events          []int
+	SeriesType      string
+	StartYear       int
+	Stories         []int
+	Title           string
+	TitleStartsWith string
+}
+
+func (sp *SeriesParams) resource() string { return "series" }
+
+func (sp *SeriesParams) validate() error {
+	for _, v := range sp.Contains {
+		if err := checkEnum("contains", v, formats); err != nil {
+			return err
+		}
+	}
+
+	return checkEnum("seriesType", sp.SeriesType, seriesTypes)
+}
+
+func (sp *SeriesParams) encode(q url.Values) {
+	setInts(q, "characters", sp.Characters)
+	setInts(q, "comics", sp.Comics)
+	if len(sp.Contains) != 0 {
+		q.Set("contains", strings.Join(sp.Contains, ","))
+	}
+	setInts(q, "creators", sp.Creators)
+	setInts(q, "events", sp.Events)
+	setString(q, "seriesType", sp.SeriesType)
+	setInt(q, "startYear", sp.StartYear)
+	setInts(q, "stories", sp.Stories)
+	setString(q, "title", sp.Title)
+	setString(q, "titleStartsWith", sp.TitleStartsWith)
+}
+
+// StoryParams contains filters of story lists.
+type StoryParams struct {
+	Characters []int
+	Comics     []int
+	Creators   []int
+	Events     []int
+	Series     []int
+}
+
+func (sp *StoryParams) resource() string { return "stories" }
+
+func (sp *StoryParams) validate() error { return nil }
+
+func (sp *StoryParams) encode(q url.Values) {
+	setInts(q, "characters", sp.Characters)
+	setInts(q, "comics", sp.Comics)
+	setInts(q, "creators", sp.Creators)
+	setInts(q, "events", sp.Events)
+	setInts(q, "series", sp.Series)
+}
+
+// allowed values from swagger/spec-1.0.json
+var (
+	dateDescriptors = []string{"lastWeek", "thisWeek", "nextWeek", "thisMonth"}
+	formats         = []string{"comic", "magazine", "trade paperback", "hardcover", "digest", "graphic novel", "digital comic", "infinite comic"}
+	formatTypes     = []string{"comic", "collection"}
+	seriesTypes     = []string{"collection", "one shot", "limited", "ongoing"}
+
+	// orderings maps listed resource to fields it can be ordered by, ascending or with "-" descending.
+	orderings = map[string][]string{
+		"characters": {"name", "modified"},
+		"comics":     {"focDate", "onsaleDate", "title", "issueNumber", "modified"},
+		"creators":   {"lastName", "firstName", "middleName", "suffix", "modified"},
+		"events":     {"name", "startDate", "modified"},
+		"series":     {"title", "modified", "startYear"},
+		"stories":    {"id", "modified"},
+	}
+
+	// filterKeys maps list endpoints to supported filters, apart from limit, offset, orderBy and modifiedSince.
+	filterKeys = map[string][]string{
+		"characters":              {"comics", "events", "name", "nameStartsWith", "series", "stories"},
+		"characters/{id}/comics":  {"collaborators", "creators", "dateDescriptor", "dateRange", "diamondCode", "digitalId", "ean", "events", "format", "formatType", "hasDigitalIssue", "isbn", "issn", "issueNumber", "noVariants", "series", "sharedAppearances", "startYear", "stories", "title", "titleStartsWith", "upc"},
+		"characters/{id}/events":  {"comics", "creators", "name", "nameStartsWith", "series", "stories"},
+		"characters/{id}/series":  {"comics", "contains", "creators", "events", "seriesType", "startYear", "stories", "title", "titleStartsWith"},
+		"characters/{id}/stories": {"comics", "creators", "events", "series"},
+		"comics":                  {"characters", "collaborators", "creators", "dateDescriptor", "dateRange", "diamondCode", "digitalId", "ean", "events", "format", "formatType", "hasDigitalIssue", "isbn", "issn", "issueNumber", "noVariants", "series", "sharedAppearances", "startYear", "stories", "title", "titleStartsWith", "upc"},
+		"comics/{id}/characters":  {"events", "name", "nameStartsWith", "series", "stories"},
+		"comics/{id}/creators":    {"comics", "firstName", "firstNameStartsWith", "lastName", "lastNameStartsWith", "middleName", "middleNameStartsWith", "nameStartsWith", "series", "stories", "suffix"},
+		"comics/{id}/events":      {"characters", "creators", "name", "nameStartsWith", "series", "stories"},
+		"comics/{id}/stories":     {"characters", "creators", "events", "series"},
+		"creators":                {"comics", "events", "firstName", "firstNameStartsWith", "lastName", "lastNameStartsWith", "middleName", "middleNameStartsWith", "nameStartsWith", "series", "stories", "suffix"},
+		"creators/{id}/comics":    {"characters", "collaborators", "dateDescriptor", "dateRange", "diamondCode", "digitalId", "ean", "events", "format", "formatType", "hasDigitalIssue", "isbn", "issn", "issueNumber", "noVariants", "series", "sharedAppearances", "startYear", "stories", "title", "titleStartsWith", "upc"},
+		"creators/{id}/events":    {"characters", "comics", "name", "nameStartsWith", "series", "stories"},
+		"creators/{id}/series":    {"characters", "comics", "contains", "events", "seriesType", "startYear", "stories", "title", "titleStartsWith"},
+		"creators/{id}/stories":   {"characters", "comics", "events", "series"},
+		"events":                  {"characters", "comics", "creators", "name", "nameStartsWith", "series", "stories"},
+		"events/{id}/characters":  {"comics", "name", "nameStartsWith", "series", "stories"},
+		"events/{id}/comics":      {"characters", "collaborators", "creators", "dateDescriptor", "dateRange", "diamondCode", "digitalId", "ean", "events", "format", "formatType", "hasDigitalIssue", "isbn", "issn", "issueNumber", "noVariants", "series", "sharedAppearances", "startYear", "stories", "title", "titleStartsWith", "upc"},
+		"events/{id}/creators":    {"comics", "firstName", "firstNameStartsWith", "lastName", "lastNameStartsWith", "middleName", "middleNameStartsWith", "nameStartsWith", "series", "stories", "suffix"},
+		"events/{id}/series":      {"characters", "comics", "contains", "creators", "seriesType", "startYear", "stories", "title", "titleStartsWith"},
+		"events/{id}/stories":     {"characters", "comics", "creators", "series"},
+		"series":                  {"characters", "comics", "contains", "creators", "events", "seriesType", "startYear", "stories", "title", "titleStartsWith"},
+		"series/{id}/characters":  {"comics", "events", "name", "nameStartsWith", "stories"},
+		"series/{id}/comics":      {"characters", "collaborators", "creators", "dateDescriptor", "dateRange", "diamondCode", "digitalId", "ean", "events", "format", "formatType", "hasDigitalIssue", "isbn", "issn", "issueNumber", "noVariants", "sharedAppearances", "startYear", "stories", "title", "titleStartsWith", "upc"},
+		"series/{id}/creators":    {"comics", "events", "firstName", "firstNameStartsWith", "lastName", "lastNameStartsWith", "middleName", "middleNameStartsWith", "nameStartsWith", "stories", "suffix"},
+		"series/{id}/events":      {"characters", "comics", "creators", "name", "nameStartsWith", "stories"},
+		"series/{id}/stories":     {"characters", "comics", "creators", "events"},
+		"stories":                 {"characters", "comics", "creators", "events", "series"},
+		"stories/{id}/characters": {"comics", "events", "name", "nameStartsWith", "series"},
+		"stories/{id}/comics":     {"characters", "collaborators", "creators", "dateDescriptor", "dateRange", "diamondCode", "digitalId", "ean", "events", "format", "formatType", "hasDigitalIssue", "isbn", "issn", "issueNumber", "noVariants", "series", "sharedAppearances", "startYear", "title", "titleStartsWith", "upc"},
+		"stories/{id}/creators":   {"comics", "events", "firstName", "firstNameStartsWith", "lastName", "lastNameStartsWith", "middleName", "middleNameStartsWith", "nameStartsWith", "series", "suffix"},
+		"stories/{id}/events":     {"characters", "comics", "creators", "name", "nameStartsWith", "series"},
+		"stories/{id}/series":     {"characters", "comics", "contains", "creators", "events", "seriesType", "startYear", "title", "titleStartsWith"},
+	}
+)
+
+// checkParams validates OrderBy and Filter of params against the endpoint they are sent to.
+func checkParams(params *Params) error {
+	listed, endpoint := params.typ, params.typ
+	if params.subtype != "" {
+		listed, endpoint = params.subtype, params.typ+"/{id}/"+params.subtype
+	}
+
+	if allowed, ok := orderings[listed]; ok && params.OrderBy != "" {
+		for _, field := range strings.Split(params.OrderBy, ",") {
+			if err := checkEnum("orderBy", strings.TrimPrefix(field, "-"), allowed); err != nil {
+				return err
+			}
+		}
+	}
+
+	if params.Filter == nil {
+		return nil
+	}
+
+	if got := params.Filter.resource(); got != listed {
+		return &FilterError{Name: "filter", Value: got, Reason: fmt.Sprintf("not applicable to %s", endpoint)}
+	}
+
+	if err := params.Filter.validate(); err != nil {
+		return err
+	}
+
+	q := url.Values{}
+	params.Filter.encode(q)
+
+	for k := range q {
+		if !contains(filterKeys[endpoint], k) {
+			return &FilterError{Name: k, Value: q.Get(k), Reason: fmt.Sprintf("not supported by %s", endpoint)}
+		}
+	}
+
+	return nil
+}
+
+func checkEnum(name, value string, allowed []string) error {
+	if value == "" || contains(allowed, value) {
+		return nil
+	}
+
+	return &FilterError{Name: name, Value: value, Reason: fmt.Sprintf("want one of %q", allowed)}
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func setBool(q url.Values, k string, v bool) {
+	if v {
+		q.Set(k, "true")
+	}
+}
+
+func setInt(q url.Values, k string, v int) {
+	if v != 0 {
+		q.Set(k, strconv.Itoa(v))
+	}
+}
+
+func setInts(q url.Values, k string, v []int) {
+	if len(v) == 0 {
+		return
+	}
+
+	ss := make([]string, len(v))
+	for i := range v {
+		ss[i] = strconv.Itoa(v[i])
+	}
+
+	q.Set(k, strings.Join(ss, ","))
+}
+
+func setString(q url.Values, k string, v string) {
+	if v != "" {
+		q.Set(k, v)
+	}
+}
@@ -0,0 +1,230 @@
+package marvel
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilter_Spec(t *testing.T) {
+	f, err := os.Open("swagger/spec-1.0.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer f.Close()
+
+	var spec struct {
+		APIs []struct {
+			Path       string
+			Operations []struct {
+				Parameters []struct {
+					Name            string
+					ParamType       string
+					AllowableValues struct {
+						Values []string
+					}
+				}
+			}
+		}
+	}
+
+	if err := json.NewDecoder(f).Decode(&spec); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	gotEndpoints := 0
+
+	for _, api := range spec.APIs {
+		parts := strings.Split(strings.TrimPrefix(api.Path, "/v1/public/"), "/")
+		if len(parts) == 2 {
+			continue // single resource
+		}
+
+		endpoint, listed := parts[0], parts[0]
+		if len(parts) == 3 {
+			endpoint, listed = parts[0]+"/{id}/"+parts[2], parts[2]
+		}
+
+		gotEndpoints++
+
+		var keys []string
+		for _, param := range api.Operations[0].Parameters {
+			if param.ParamType != "query" {
+				continue
+			}
+
+			switch param.Name {
+			case "apikey", "hash", "ts", "limit", "offset", "modifiedSince":
+				continue
+			case "orderBy":
+				for _, v := range param.AllowableValues.Values {
+					if !strings.HasPrefix(v, "-") && !contains(orderings[listed], v) {
+						t.Errorf("%s: orderBy %q missing", endpoint, v)
+					}
+				}
+				continue
+			case "format", "contains":
+				if !reflect.DeepEqual(param.AllowableValues.Values, formats) {
+					t.Errorf("%s: got %s values %q, want %q", endpoint, param.Name, formats, param.AllowableValues.Values)
+				}
+			case "formatType":
+				if !reflect.DeepEqual(param.AllowableValues.Values, formatTypes) {
+					t.Errorf("%s: got formatType values %q, want %q", endpoint, formatTypes, param.AllowableValues.Values)
+				}
+			case "dateDescriptor":
+				if !reflect.DeepEqual(param.AllowableValues.Values, dateDescriptors) {
+					t.Errorf("%s: got dateDescriptor values %q, want %q", endpoint, dateDescriptors, param.AllowableValues.Values)
+				}
+			case "seriesType":
+				if !reflect.DeepEqual(param.AllowableValues.Values, seriesTypes) {
+					t.Errorf("%s: got seriesType values %q, want %q", endpoint, seriesTypes, param.AllowableValues.Values)
+				}
+			}
+
+			keys = append(keys, param.Name)
+		}
+
+		sort.Strings(keys)
+
+		if got, want := filterKeys[endpoint], keys; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got filter keys %q, want %q", endpoint, got, want)
+		}
+	}
+
+	if got, want := gotEndpoints, len(filterKeys); got != want {
+		t.Errorf("got %d list endpoints in spec, want %d", got, want)
+	}
+}
+
+func TestFilter_Encode(t *testing.T) {
+	date := time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	// every field set to cover all keys of the endpoint family
+	for _, filter := range []Filter{
+		&CharacterParams{Comics: []int{1}, Events: []int{1}, Name: "a", NameStartsWith: "a", Series: []int{1}, Stories: []int{1}},
+		&ComicParams{
+			Characters: []int{1}, Collaborators: []int{1}, Creators: []int{1}, DateDescriptor: "thisWeek", DateRange: []time.Time{date, date},
+			DiamondCode: "a", DigitalID: 1, EAN: "a", Events: []int{1}, Format: "comic", FormatType: "comic", HasDigitalIssue: true,
+			ISBN: "a", ISSN: "a", IssueNumber: 1, NoVariants: true, Series: []int{1}, SharedAppearances: []int{1}, StartYear: 1,
+			Stories: []int{1}, Title: "a", TitleStartsWith: "a", UPC: "a",
+		},
+		&CreatorParams{
+			Comics: []int{1}, Events: []int{1}, FirstName: "a", FirstNameStartsWith: "a", LastName: "a", LastNameStartsWith: "a",
+			MiddleName: "a", MiddleNameStartsWith: "a", NameStartsWith: "a", Series: []int{1}, Stories: []int{1}, Suffix: "a",
+		},
+		&EventParams{Characters: []int{1}, Comics: []int{1}, Creators: []int{1}, Name: "a", NameStartsWith: "a", Series: []int{1}, Stories: []int{1}},
+		&SeriesParams{
+			Characters: []int{1}, Comics: []int{1}, Contains: []string{"comic"}, Creators: []int{1}, Events: []int{1},
+			SeriesType: "ongoing", StartYear: 1, Stories: []int{1}, Title: "a", TitleStartsWith: "a",
+		},
+		&StoryParams{Characters: []int{1}, Comics: []int{1}, Creators: []int{1}, Events: []int{1}, Series: []int{1}},
+	} {
+		t.Run(filter.resource(), func(t *testing.T) {
+			if err := filter.validate(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			q := url.Values{}
+			filter.encode(q)
+
+			var keys []string
+			for k := range q {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			if got, want := keys, filterKeys[filter.resource()]; !reflect.DeepEqual(got, want) {
+				t.Errorf("got keys %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func Test_CheckParams(t *testing.T) {
+	id := 123
+
+	t.Run("Success", func(t *testing.T) {
+		for _, tc := range []struct {
+			desc   string
+			params *Params
+		}{
+			{
+				desc:   "NoFilter",
+				params: &Params{typ: "comics", OrderBy: "modified"},
+			},
+			{
+				desc:   "UnknownType",
+				params: &Params{typ: "foo", OrderBy: "bar"},
+			},
+			{
+				desc:   "Collection",
+				params: &Params{typ: "comics", OrderBy: "-onsaleDate,title", Filter: &ComicParams{Format: "hardcover", Characters: []int{1, 2}}},
+			},
+			{
+				desc:   "SubResource",
+				params: &Params{typ: "characters", id: &id, subtype: "comics", Filter: &ComicParams{NoVariants: true}},
+			},
+		} {
+			t.Run(tc.desc, func(t *testing.T) {
+				if err := checkParams(tc.params); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			})
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		for _, tc := range []struct {
+			desc   string
+			params *Params
+			want   string
+		}{
+			{
+				desc:   "InvalidOrderBy",
+				params: &Params{typ: "stories", OrderBy: "title"},
+				want:   "orderBy",
+			},
+			{
+				desc:   "WrongResource",
+				params: &Params{typ: "comics", Filter: &SeriesParams{}},
+				want:   "filter",
+			},
+			{
+				desc:   "InvalidEnum",
+				params: &Params{typ: "series", Filter: &SeriesParams{SeriesType: "foo"}},
+				want:   "seriesType",
+			},
+			{
+				desc:   "InvalidDateRange",
+				params: &Params{typ: "comics", Filter: &ComicParams{DateRange: []time.Time{time.Now()}}},
+				want:   "dateRange",
+			},
+			{
+				desc:   "UnsupportedBySubResource",
+				params: &Params{typ: "characters", id: &id, subtype: "comics", Filter: &ComicParams{Characters: []int{1}}},
+				want:   "characters",
+			},
+		} {
+			t.Run(tc.desc, func(t *testing.T) {
+				err := checkParams(tc.params)
+				if err == nil {
+					t.Fatal("error is nil")
+				}
+
+				fe, ok := err.(*FilterError)
+				if !ok {
+					t.Fatalf("got error type %T, want %T", err, fe)
+				}
+
+				if got, want := fe.Name, tc.want; got != want {
+					t.Errorf("got invalid param %q, want %q", got, want)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package marvel
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Key is a pair of api keys of one developer account.
+type Key struct {
+	Private string
+	Public  string
+}
+
+// WithKeys adds keys to the pool of Client along with the pair passed to NewClient.
+// Calls are spread across keys in turn, each one counted against its own quota if any.
+// A key refused by api with 401 or 429 is out of rotation until next utc day,
+// and the call is sent again with the next key.
+// A single key is never out of rotation, so calls keep going to api as without a pool.
+func WithKeys(keys ...Key) Option {
+	return func(c *Client) {
+		c.keys.keys = append(c.keys.keys, keys...)
+	}
+}
+
+// keyPool rotates keys, skipping those refused by api.
+type keyPool struct {
+	mu      sync.Mutex
+	keys    []Key
+	next    int
+	benched map[string]time.Time // map of public key to end of its time out of rotation
+	now     func() time.Time
+}
+
+// candidates returns keys in rotation starting from the next one, moving rotation forward by one.
+// If every key is benched, it returns *KeyError of the key back earliest.
+func (kp *keyPool) candidates() ([]Key, error) {
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
+
+	keys, err := kp.rotation()
+
+	kp.next = (kp.next + 1) % len(kp.keys)
+
+	return keys, err
+}
+
+// available returns keys in rotation, or *KeyError if every key is benched.
+func (kp *keyPool) available() ([]Key, error) {
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
+
+	return kp.rotation()
+}
+
+func (kp *keyPool) rotation() ([]Key, error) {
+	now := kp.clock()
+
+	var keys []Key
+	var earliest *KeyError
+
+	for i := range kp.keys {
+		key := kp.keys[(kp.next+i)%len(kp.keys)]
+
+		until, ok := kp.benched[key.Public]
+		if ok && now.Before(until) {
+			if earliest == nil || until.Before(earliest.Reset) {
+				earliest = &KeyError{Key: key.Public, Reset: until}
+			}
+			continue
+		}
+
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, earliest
+	}
+
+	return keys, nil
+}
+
+// bench takes key out of rotation until next utc day, if there are other keys.
+// It returns whether key was benched.
+func (kp *keyPool) bench(key Key, code int) bool {
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
+
+	if len(kp.keys) < 2 {
+		return false
+	}
+
+	if kp.benched == nil {
+		kp.benched = make(map[string]time.Time)
+	}
+
+	until := nextDay(kp.clock())
+	kp.benched[key.Public] = until
+
+	log.Warn().Str("key", key.Public).Int("code", code).Time("until", until).Msg("api key out of rotation")
+
+	return true
+}
+
+func (kp *keyPool) clock() time.Time {
+	if kp.now == nil {
+		return time.Now()
+	}
+
+	return kp.now()
+}
+
+// acquireKey returns the key of the next call, counted against its quota if any.
+// Keys with calls left are tried in turn, and only when none is left it waits for quota if Quota.Wait is set.
+func (c *Client) acquireKey(ctx context.Context) (Key, error) {
+	keys, err := c.keys.candidates()
+	if err != nil {
+		return Key{}, err
+	}
+
+	if c.quota == nil {
+		return keys[0], nil
+	}
+
+	for _, key := range keys {
+		err = c.quota.take(key.Public)
+		if err == nil {
+			return key, nil
+		}
+
+		if _, ok := err.(*QuotaError); !ok {
+			return Key{}, err
+		}
+	}
+
+	if !c.quota.Wait {
+		return Key{}, err
+	}
+
+	if err := c.quota.acquire(ctx, keys[0].Public); err != nil {
+		return Key{}, err
+	}
+
+	return keys[0], nil
+}
@@ -0,0 +1,137 @@
+package marvel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestClient_GetWithKeys(t *testing.T) {
+	server := func(refused map[string]int) (*httptest.Server, map[string]int) {
+		var mu sync.Mutex
+		calls := make(map[string]int)
+
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.URL.Query().Get("apikey")
+
+			mu.Lock()
+			calls[key]++
+			mu.Unlock()
+
+			if code, ok := refused[key]; ok {
+				http.Error(w, "", code)
+				return
+			}
+
+			w.Write([]byte(`{}`))
+		})), calls
+	}
+
+	t.Run("Rotation", func(t *testing.T) {
+		ts, calls := server(nil)
+		defer ts.Close()
+
+		c := NewClient(ts.URL, "", "foo", WithKeys(Key{Public: "bar"}))
+
+		for i := 0; i < 4; i++ {
+			if _, err := c.get(context.Background(), &Params{typ: "comics"}); err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+		}
+
+		if got, want := calls, map[string]int{"foo": 2, "bar": 2}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got calls %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Refused", func(t *testing.T) {
+		ts, calls := server(map[string]int{"foo": http.StatusTooManyRequests, "bar": http.StatusUnauthorized})
+		defer ts.Close()
+
+		c := NewClient(ts.URL, "", "foo", WithKeys(Key{Public: "bar"}, Key{Public: "baz"}))
+
+		for i := 0; i < 3; i++ {
+			if _, err := c.get(context.Background(), &Params{typ: "comics"}); err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+		}
+
+		if got, want := calls, map[string]int{"foo": 1, "bar": 1, "baz": 3}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got calls %v, want %v", got, want)
+		}
+	})
+
+	t.Run("AllRefused", func(t *testing.T) {
+		ts, _ := server(map[string]int{"foo": http.StatusTooManyRequests, "bar": http.StatusTooManyRequests})
+		defer ts.Close()
+
+		c := NewClient(ts.URL, "", "foo", WithKeys(Key{Public: "bar"}))
+
+		_, err := c.get(context.Background(), &Params{typ: "comics"})
+
+		if _, ok := err.(*KeyError); !ok {
+			t.Fatalf("got error type %T, want %T", err, &KeyError{})
+		}
+
+		remaining, ok := c.Remaining()
+		if !ok {
+			t.Fatal("got no quota")
+		}
+
+		if got, want := remaining, 0; got != want {
+			t.Errorf("got remaining %d, want %d", got, want)
+		}
+	})
+
+	t.Run("SingleKeyRefused", func(t *testing.T) {
+		ts, calls := server(map[string]int{"foo": http.StatusTooManyRequests})
+		defer ts.Close()
+
+		c := NewClient(ts.URL, "", "foo")
+
+		for i := 0; i < 2; i++ {
+			_, err := c.get(context.Background(), &Params{typ: "comics"})
+
+			if _, ok := err.(*RateLimitError); !ok {
+				t.Fatalf("got error type %T, want %T", err, &RateLimitError{})
+			}
+		}
+
+		if got, want := calls["foo"], 2; got != want {
+			t.Errorf("got %d calls, want %d", got, want)
+		}
+	})
+
+	t.Run("Quota", func(t *testing.T) {
+		ts, calls := server(nil)
+		defer ts.Close()
+
+		c := NewClient(ts.URL, "", "foo", WithKeys(Key{Public: "bar"}), WithQuota(&Quota{Budget: 2}))
+
+		for i := 0; i < 4; i++ {
+			if _, err := c.get(context.Background(), &Params{typ: "comics"}); err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			remaining, ok := c.Remaining()
+			if !ok {
+				t.Fatal("got no quota")
+			}
+
+			if got, want := remaining, 3-i; got != want {
+				t.Errorf("got remaining %d, want %d", got, want)
+			}
+		}
+
+		if _, err := c.get(context.Background(), &Params{typ: "comics"}); err == nil {
+			t.Fatal("error is nil")
+		}
+
+		if got, want := calls, map[string]int{"foo": 2, "bar": 2}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got calls %v, want %v", got, want)
+		}
+	})
+}
@@ -1,21 +1,14 @@
 package marvel
 
-import (
-	"encoding/json"
-	"fmt"
-	"reflect"
-	"strconv"
-)
-
 // Character .
 type Character struct {
 	Comics      *ComicList
-	Description string
+	Description String
 	Events      *EventList
-	ID          int
-	Modified    string
-	Name        string
-	ResourceURI string
+	ID          Int
+	Modified    String
+	Name        String
+	ResourceURI String
 	Series      *SeriesList
 	Stories     *StoryList
 	Thumbnail   *Image
@@ -24,17 +17,17 @@ type Character struct {
 
 // CharacterList .
 type CharacterList struct {
-	Available     int
-	CollectionURI string
+	Available     Int
+	CollectionURI String
 	Items         []*CharacterSummary
-	Returned      int
+	Returned      Int
 }
 
 // CharacterSummary .
 type CharacterSummary struct {
-	Name        string
-	ResourceURI string
-	Role        string
+	Name        String
+	ResourceURI String
+	Role        String
 }
 
 // Comic .
@@ -44,203 +37,89 @@ type Comic struct {
 	Collections        []*ComicSummary
 	Creators           *CreatorList
 	Dates              []*ComicDate
-	Description        string
-	DiamondCode        string
-	DigitalID          int
-	Ean                string
+	Description        String
+	DiamondCode        String
+	DigitalID          Int
+	Ean                String
 	Events             *EventList
-	Format             string
-	ID                 int
+	Format             String
+	ID                 Int
 	Images             []*Image
-	ISBN               string
-	ISSN               string
-	IssueNumber        float64
-	Modified           string
-	PageCount          int
+	ISBN               String
+	ISSN               String
+	IssueNumber        Float
+	Modified           String
+	PageCount          Int
 	Prices             []*ComicPrice
-	ResourceURI        string
+	ResourceURI        String
 	Series             *SeriesSummary
 	Stories            *StoryList
 	TextObjects        []*TextObject
 	Thumbnail          *Image
-	Title              string
-	UPC                string
+	Title              String
+	UPC                String
 	URLs               []*URL
-	VariantDescription string
+	VariantDescription String
 	Variants           []*ComicSummary
 }
 
-// UnmarshalJSON handles mismatched data type in api response.
-func (comic *Comic) UnmarshalJSON(data []byte) error {
-	typ := reflect.TypeOf(comic)
-
-	type Alias Comic
-
-	aux := &struct {
-		DiamondCode interface{}
-		ISBN        interface{}
-		*Alias
-	}{
-		Alias: (*Alias)(comic),
-	}
-	if err := json.Unmarshal(data, &aux); err != nil {
-		if v, ok := err.(*json.UnmarshalTypeError); ok {
-			v.Struct = typ.String()
-		}
-		return err
-	}
-
-	switch v := aux.DiamondCode.(type) {
-	case nil:
-	case string:
-		comic.DiamondCode = v
-	case int:
-		comic.DiamondCode = strconv.FormatInt(int64(v), 10)
-	case float64:
-		comic.DiamondCode = strconv.FormatFloat(float64(v), 'f', -1, 64)
-	default:
-		return &json.UnmarshalTypeError{
-			Value:  fmt.Sprintf("%T", v),
-			Type:   typ,
-			Struct: typ.String(),
-			Field:  "DiamondCode",
-		}
-	}
-
-	switch v := aux.ISBN.(type) {
-	case nil:
-	case string:
-		comic.ISBN = v
-	case int:
-		comic.ISBN = strconv.FormatInt(int64(v), 10)
-	case float64:
-		comic.ISBN = strconv.FormatFloat(float64(v), 'f', -1, 64)
-	default:
-		return &json.UnmarshalTypeError{
-			Value:  fmt.Sprintf("%T", v),
-			Type:   typ,
-			Struct: typ.String(),
-			Field:  "ISBN",
-		}
-	}
-
-	return nil
-}
-
 // ComicDate .
 type ComicDate struct {
-	Date string
-	Type string
+	Date String
+	Type String
 }
 
 // ComicList .
 type ComicList struct {
-	Available     int
-	CollectionURI string
+	Available     Int
+	CollectionURI String
 	Items         []*ComicSummary
-	Returned      int
+	Returned      Int
 }
 
 // ComicPrice .
 type ComicPrice struct {
-	Price float32
-	Type  string
+	Price Float
+	Type  String
 }
 
 // ComicSummary .
 type ComicSummary struct {
-	Name        string
-	ResourceURI string
+	Name        String
+	ResourceURI String
 }
 
 // Creator .
 type Creator struct {
 	Comics      *ComicList
 	Events      *EventList
-	FirstName   string
-	FullName    string
-	ID          int
-	LastName    string
-	MiddleName  string
-	Modified    string
-	ResourceURI string
+	FirstName   String
+	FullName    String
+	ID          Int
+	LastName    String
+	MiddleName  String
+	Modified    String
+	ResourceURI String
 	Series      *SeriesList
 	Stories     *StoryList
-	Suffix      string
+	Suffix      String
 	Thumbnail   *Image
 	URLs        []*URL
 }
 
-// UnmarshalJSON handles mismatched data type in api response.
-func (creator *Creator) UnmarshalJSON(data []byte) error {
-	typ := reflect.TypeOf(creator)
-
-	type Alias Creator
-
-	aux := &struct {
-		LastName interface{}
-		Suffix   interface{}
-		*Alias
-	}{
-		Alias: (*Alias)(creator),
-	}
-	if err := json.Unmarshal(data, &aux); err != nil {
-		if v, ok := err.(*json.UnmarshalTypeError); ok {
-			v.Struct = typ.String()
-		}
-		return err
-	}
-
-	switch v := aux.LastName.(type) {
-	case nil:
-	case string:
-		creator.LastName = v
-	case int:
-		creator.LastName = strconv.FormatInt(int64(v), 10)
-	case float64:
-		creator.LastName = strconv.FormatFloat(float64(v), 'f', -1, 64)
-	default:
-		return &json.UnmarshalTypeError{
-			Value:  fmt.Sprintf("%T", v),
-			Type:   typ,
-			Struct: typ.String(),
-			Field:  "LastName",
-		}
-	}
-
-	switch v := aux.Suffix.(type) {
-	case nil:
-	case string:
-		creator.Suffix = v
-	case int:
-		creator.Suffix = strconv.FormatInt(int64(v), 10)
-	case float64:
-		creator.Suffix = strconv.FormatFloat(float64(v), 'f', -1, 64)
-	default:
-		return &json.UnmarshalTypeError{
-			Value:  fmt.Sprintf("%T", v),
-			Type:   typ,
-			Struct: typ.String(),
-			Field:  "Suffix",
-		}
-	}
-
-	return nil
-}
-
 // CreatorList .
 type CreatorList struct {
-	Available     int
-	CollectionURI string
+	Available     Int
+	CollectionURI String
 	Items         []*CreatorSummary
-	Returned      int
+	Returned      Int
 }
 
 // CreatorSummary .
 type CreatorSummary struct {
-	Name        string
-	ResourceURI string
-	Role        string
+	Name        String
+	ResourceURI String
+	Role        String
 }
 
 // Event .
@@ -248,33 +127,33 @@ type Event struct {
 	Characters  *CharacterList
 	Comics      *ComicList
 	Creators    *CreatorList
-	Description string
-	End         string
-	ID          int
-	Modified    string
+	Description String
+	End         String
+	ID          Int
+	Modified    String
 	Next        *EventSummary
 	Previous    *EventSummary
-	ResourceURI string
+	ResourceURI String
 	Series      *SeriesList
-	Start       string
+	Start       String
 	Stories     *StoryList
 	Thumbnail   *Image
-	Title       string
+	Title       String
 	URLs        []*URL
 }
 
 // EventList .
 type EventList struct {
-	Available     int
-	CollectionURI string
+	Available     Int
+	CollectionURI String
 	Items         []*EventSummary
-	Returned      int
+	Returned      Int
 }
 
 // EventSummary .
 type EventSummary struct {
-	Name        string
-	ResourceURI string
+	Name        String
+	ResourceURI String
 }
 
 // Series .
@@ -282,34 +161,34 @@ type Series struct {
 	Characters  *CharacterList
 	Comics      *ComicList
 	Creators    *CreatorList
-	Description string
-	EndYear     int
+	Description String
+	EndYear     Int
 	Events      *EventList
-	ID          int
-	Modified    string
+	ID          Int
+	Modified    String
 	Next        *SeriesSummary
 	Previous    *SeriesSummary
-	Rating      string
-	ResourceURI string
-	StartYear   int
+	Rating      String
+	ResourceURI String
+	StartYear   Int
 	Stories     *StoryList
 	Thumbnail   *Image
-	Title       string
+	Title       String
 	URLs        []*URL
 }
 
 // SeriesList .
 type SeriesList struct {
-	Available     int
-	CollectionURI string
+	Available     Int
+	CollectionURI String
 	Items         []*SeriesSummary
-	Returned      int
+	Returned      Int
 }
 
 // SeriesSummary .
 type SeriesSummary struct {
-	Name        string
-	ResourceURI string
+	Name        String
+	ResourceURI String
 }
 
 // Story .
@@ -317,105 +196,48 @@ type Story struct {
 	Characters    *CharacterList
 	Comics        *ComicList
 	Creators      *CreatorList
-	Description   string
+	Description   String
 	Events        *EventList
-	ID            int
-	Modified      string
+	ID            Int
+	Modified      String
 	OriginalIssue *ComicSummary
-	ResourceURI   string
+	ResourceURI   String
 	Series        *SeriesList
 	Thumbnail     *Image
-	Title         string
-	Type          string
-}
-
-// UnmarshalJSON handles mismatched data type in api response.
-func (story *Story) UnmarshalJSON(data []byte) error {
-	typ := reflect.TypeOf(story)
-
-	type Alias Story
-
-	aux := &struct {
-		Description interface{}
-		Title       interface{}
-		*Alias
-	}{
-		Alias: (*Alias)(story),
-	}
-	if err := json.Unmarshal(data, &aux); err != nil {
-		if v, ok := err.(*json.UnmarshalTypeError); ok {
-			v.Struct = typ.String()
-		}
-		return err
-	}
-
-	switch v := aux.Description.(type) {
-	case nil:
-	case string:
-		story.Description = v
-	case int:
-		story.Description = strconv.FormatInt(int64(v), 10)
-	case float64:
-		story.Description = strconv.FormatFloat(float64(v), 'f', -1, 64)
-	default:
-		return &json.UnmarshalTypeError{
-			Value:  fmt.Sprintf("%T", v),
-			Type:   typ,
-			Struct: typ.String(),
-			Field:  "Description",
-		}
-	}
-
-	switch v := aux.Title.(type) {
-	case nil:
-	case string:
-		story.Title = v
-	case int:
-		story.Title = strconv.FormatInt(int64(v), 10)
-	case float64:
-		story.Title = strconv.FormatFloat(float64(v), 'f', -1, 64)
-	default:
-		return &json.UnmarshalTypeError{
-			Value:  fmt.Sprintf("%T", v),
-			Type:   typ,
-			Struct: typ.String(),
-			Field:  "Title",
-		}
-	}
-
-	return nil
+	Title         String
+	Type          String
 }
 
 // StoryList .
 type StoryList struct {
-	Available     int
-	CollectionURI string
+	Available     Int
+	CollectionURI String
 	Items         []*StorySummary
-	Returned      int
+	Returned      Int
 }
 
 // StorySummary .
 type StorySummary struct {
-	Name        string
-	ResourceURI string
-	Type        string
+	Name        String
+	ResourceURI String
+	Type        String
 }
 
 // Image .
 type Image struct {
-	Extension string
-	Path      string
+	Extension String
+	Path      String
 }
 
 // TextObject .
 type TextObject struct {
-	Language string
-	Text     string
-	Type     string
+	Language String
+	Text     String
+	Type     String
 }
 
 // URL .
 type URL struct {
-	Type string
-	URL  string
+	Type String
+	URL  String
 }
@@ -18,6 +18,16 @@ func TestComic_UnmarshalJSON(t *testing.T) {
 				b:    []byte(`{"id":1}`),
 				want: Comic{ID: 1},
 			},
+			{
+				desc: "StringID",
+				b:    []byte(`{"id":"1"}`),
+				want: Comic{ID: 1},
+			},
+			{
+				desc: "NullID",
+				b:    []byte(`{"id":null}`),
+				want: Comic{},
+			},
 			{
 				desc: "String",
 				b:    []byte(`{"id":1,"diamondCode":"foo", "isbn":"bar"}`),
@@ -33,6 +43,16 @@ func TestComic_UnmarshalJSON(t *testing.T) {
 				b:    []byte(`{"id":1,"diamondCode":12.34, "isbn":56.78}`),
 				want: Comic{ID: 1, DiamondCode: "12.34", ISBN: "56.78"},
 			},
+			{
+				desc: "Bool",
+				b:    []byte(`{"id":1,"diamondCode":false, "isbn":true}`),
+				want: Comic{ID: 1, DiamondCode: "false", ISBN: "true"},
+			},
+			{
+				desc: "Null",
+				b:    []byte(`{"id":1,"diamondCode":null, "isbn":null}`),
+				want: Comic{ID: 1},
+			},
 		} {
 			t.Run(tc.desc, func(t *testing.T) {
 				var gotComic Comic
@@ -69,21 +89,6 @@ func TestComic_UnmarshalJSON(t *testing.T) {
 				in:   []byte(`{invalid-json`),
 				err:  &json.SyntaxError{},
 			},
-			{
-				desc: "InvalidTypeID",
-				in:   []byte(`{"id":false}`),
-				err:  &json.UnmarshalTypeError{},
-			},
-			{
-				desc: "InvalidTypeDiamondCode",
-				in:   []byte(`{"id":1, "DiamondCode":false}`),
-				err:  &json.UnmarshalTypeError{},
-			},
-			{
-				desc: "InvalidTypeISBN",
-				in:   []byte(`{"id":1, "ISBN":false}`),
-				err:  &json.UnmarshalTypeError{},
-			},
 		} {
 			t.Run(tc.desc, func(t *testing.T) {
 				var comic Comic
@@ -114,6 +119,16 @@ func TestCreator_UnmarshalJSON(t *testing.T) {
 				b:    []byte(`{"id":1}`),
 				want: Creator{ID: 1},
 			},
+			{
+				desc: "StringID",
+				b:    []byte(`{"id":"1"}`),
+				want: Creator{ID: 1},
+			},
+			{
+				desc: "NullID",
+				b:    []byte(`{"id":null}`),
+				want: Creator{},
+			},
 			{
 				desc: "String",
 				b:    []byte(`{"id":1,"lastName":"foo", "suffix":"bar"}`),
@@ -129,6 +144,16 @@ func TestCreator_UnmarshalJSON(t *testing.T) {
 				b:    []byte(`{"id":1,"lastName":12.34, "suffix":56.78}`),
 				want: Creator{ID: 1, LastName: "12.34", Suffix: "56.78"},
 			},
+			{
+				desc: "Bool",
+				b:    []byte(`{"id":1,"lastName":false, "suffix":true}`),
+				want: Creator{ID: 1, LastName: "false", Suffix: "true"},
+			},
+			{
+				desc: "Null",
+				b:    []byte(`{"id":1,"lastName":null, "suffix":null}`),
+				want: Creator{ID: 1},
+			},
 		} {
 			t.Run(tc.desc, func(t *testing.T) {
 				var gotCreator Creator
@@ -165,21 +190,6 @@ func TestCreator_UnmarshalJSON(t *testing.T) {
 				in:   []byte(`{invalid-json`),
 				err:  &json.SyntaxError{},
 			},
-			{
-				desc: "InvalidTypeID",
-				in:   []byte(`{"id":false}`),
-				err:  &json.UnmarshalTypeError{},
-			},
-			{
-				desc: "InvalidTypeLastName",
-				in:   []byte(`{"id":1, "LastName":false}`),
-				err:  &json.UnmarshalTypeError{},
-			},
-			{
-				desc: "InvalidTypeSuffix",
-				in:   []byte(`{"id":1, "Suffix":false}`),
-				err:  &json.UnmarshalTypeError{},
-			},
 		} {
 			t.Run(tc.desc, func(t *testing.T) {
 				var creator Creator
@@ -210,6 +220,16 @@ func TestStory_UnmarshalJSON(t *testing.T) {
 				b:    []byte(`{"id":1}`),
 				want: Story{ID: 1},
 			},
+			{
+				desc: "StringID",
+				b:    []byte(`{"id":"1"}`),
+				want: Story{ID: 1},
+			},
+			{
+				desc: "NullID",
+				b:    []byte(`{"id":null}`),
+				want: Story{},
+			},
 			{
 				desc: "String",
 				b:    []byte(`{"id":1,"description":"foo","title":"bar"}`),
@@ -225,6 +245,16 @@ func TestStory_UnmarshalJSON(t *testing.T) {
 				b:    []byte(`{"id":1,"description":12.34,"title":56.78}`),
 				want: Story{ID: 1, Description: "12.34", Title: "56.78"},
 			},
+			{
+				desc: "Bool",
+				b:    []byte(`{"id":1,"description":false,"title":true}`),
+				want: Story{ID: 1, Description: "false", Title: "true"},
+			},
+			{
+				desc: "Null",
+				b:    []byte(`{"id":1,"description":null,"title":null}`),
+				want: Story{ID: 1},
+			},
 		} {
 			t.Run(tc.desc, func(t *testing.T) {
 				var gotStory Story
@@ -256,21 +286,6 @@ func TestStory_UnmarshalJSON(t *testing.T) {
 				in:   []byte(`{invalid-json`),
 				err:  &json.SyntaxError{},
 			},
-			{
-				desc: "InvalidTypeID",
-				in:   []byte(`{"id":false}`),
-				err:  &json.UnmarshalTypeError{},
-			},
-			{
-				desc: "InvalidTypeDescription",
-				in:   []byte(`{"id":1, "Description":false}`),
-				err:  &json.UnmarshalTypeError{},
-			},
-			{
-				desc: "InvalidTypeTitle",
-				in:   []byte(`{"id":1, "Title":false}`),
-				err:  &json.UnmarshalTypeError{},
-			},
 		} {
 			t.Run(tc.desc, func(t *testing.T) {
 				var story Story
@@ -0,0 +1,591 @@
+// Package marveltest provides a fake of the marvel comics api gateway for tests.
+//
+// Server serves all 39 paths of swagger/spec-1.0.json from an in-memory dataset,
+// checking auth params and honouring limit, offset, orderBy and modifiedSince like the real gateway.
+// Other filters are ignored. Faults can be injected to answer with errors, delays or repeated pages.
+package marveltest
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BasePath is the path prefix of all api paths, to be appended to Server.URL as base url of a client.
+const BasePath = "/v1/public"
+
+const timeLayout = "2006-01-02T15:04:05-0700"
+
+// subtypes maps each resource type to resource types listed under it, e.g. /comics/{id}/characters.
+var subtypes = map[string][]string{
+	"characters": {"comics", "events", "series", "stories"},
+	"comics":     {"characters", "creators", "events", "stories"},
+	"creators":   {"comics", "events", "series", "stories"},
+	"events":     {"characters", "comics", "creators", "series", "stories"},
+	"series":     {"characters", "comics", "creators", "events", "stories"},
+	"stories":    {"characters", "comics", "creators", "events", "series"},
+}
+
+// orderings maps each resource type to fields it can be ordered by.
+var orderings = map[string][]string{
+	"characters": {"name", "modified"},
+	"comics":     {"focDate", "onsaleDate", "title", "issueNumber", "modified"},
+	"creators":   {"lastName", "firstName", "middleName", "suffix", "modified"},
+	"events":     {"name", "startDate", "modified"},
+	"series":     {"title", "modified", "startYear"},
+	"stories":    {"id", "modified"},
+}
+
+// Resource is one entity as decoded from api json, e.g. a comic.
+type Resource map[string]interface{}
+
+// ID returns the id of the resource, 0 if missing.
+func (r Resource) ID() int {
+	id, _ := r["id"].(float64)
+	return int(id)
+}
+
+// Fault is an error injected into responses of Server.
+type Fault struct {
+	Match     func(r *http.Request) bool // requests affected, all if nil
+	Times     int                        // number of requests affected, unlimited if 0
+	Status    int                        // status to answer with instead of a response, e.g. 409, 429 or 500
+	Delay     time.Duration              // delay before answering, e.g. to trigger client timeouts
+	Duplicate bool                       // answer list requests with results of the previous page, as the real api sometimes does
+	Hide      []int                      // ids left out of list results though counted in total, as the real api does for some entities
+
+	hits int
+}
+
+// Server is a fake of the marvel comics api gateway.
+type Server struct {
+	*httptest.Server
+
+	PrivateKey string
+	PublicKey  string
+
+	mu       sync.Mutex
+	data     map[string]map[int]Resource
+	faults   []*Fault
+	requests int
+}
+
+// NewServer starts and returns a Server accepting requests signed with privateKey and publicKey.
+// Callers should call Close when finished.
+func NewServer(privateKey, publicKey string) *Server {
+	s := &Server{
+		PrivateKey: privateKey,
+		PublicKey:  publicKey,
+		data:       make(map[string]map[int]Resource),
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+
+	return s
+}
+
+// Load adds resources of typ from b, either a full api response like client/marvel/data/comics.json
+// or a json array of resources.
+func (s *Server) Load(typ string, b []byte) error {
+	if _, ok := subtypes[typ]; !ok {
+		return fmt.Errorf("unsupported type: %s", typ)
+	}
+
+	var resources []Resource
+	if err := json.Unmarshal(b, &resources); err != nil {
+		var resp struct {
+			Data struct {
+				Results []Resource
+			}
+		}
+		if err := json.Unmarshal(b, &resp); err != nil {
+			return fmt.Errorf("error decoding %s: %v", typ, err)
+		}
+		resources = resp.Data.Results
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data[typ] == nil {
+		s.data[typ] = make(map[int]Resource)
+	}
+
+	for _, r := range resources {
+		s.data[typ][r.ID()] = r
+	}
+
+	return nil
+}
+
+// LoadFile adds resources of typ from the json file at path, see Load.
+func (s *Server) LoadFile(typ, path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return s.Load(typ, b)
+}
+
+// Inject adds fault to responses, faults are checked in the order injected.
+func (s *Server) Inject(fault *Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, fault)
+}
+
+// Requests returns the number of requests received so far.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	fault := s.fault(r)
+
+	if fault != nil && fault.Delay > 0 {
+		select {
+		case <-time.After(fault.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if fault != nil && fault.Status != 0 {
+		writeError(w, fault.Status, "Injected", http.StatusText(fault.Status))
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", fmt.Sprintf("%s is not allowed.", r.Method))
+		return
+	}
+
+	if !s.authorize(w, r) {
+		return
+	}
+
+	typ, id, subtype, ok := route(r.URL.Path)
+	if !ok {
+		writeError(w, http.StatusNotFound, "ResourceNotFound", fmt.Sprintf("%s does not exist", r.URL.Path))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id != 0 {
+		parent, ok := s.data[typ][id]
+		if !ok {
+			writeError(w, http.StatusNotFound, strconv.Itoa(http.StatusNotFound), fmt.Sprintf("We couldn't find that %s", typ))
+			return
+		}
+
+		if subtype == "" {
+			s.writeList(w, r, typ, []Resource{parent}, fault)
+			return
+		}
+
+		s.writeList(w, r, subtype, s.related(typ, id, parent, subtype), fault)
+		return
+	}
+
+	var resources []Resource
+	for _, res := range s.data[typ] {
+		resources = append(resources, res)
+	}
+
+	s.writeList(w, r, typ, resources, fault)
+}
+
+// fault returns the first injected fault matching r, counting r against it.
+func (s *Server) fault(r *http.Request) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+
+	for _, f := range s.faults {
+		if f.Times > 0 && f.hits >= f.Times {
+			continue
+		}
+
+		if f.Match != nil && !f.Match(r) {
+			continue
+		}
+
+		f.hits++
+
+		return f
+	}
+
+	return nil
+}
+
+// authorize checks apikey, ts and hash params like the real gateway, writing an error if invalid.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) bool {
+	q := r.URL.Query()
+
+	apikey, ts, hash := q.Get("apikey"), q.Get("ts"), q.Get("hash")
+
+	switch {
+	case apikey == "":
+		writeError(w, http.StatusConflict, "MissingParameter", "You must provide a user key.")
+	case ts == "":
+		writeError(w, http.StatusConflict, "MissingParameter", "You must provide a timestamp.")
+	case hash == "":
+		writeError(w, http.StatusConflict, "MissingParameter", "You must provide a hash.")
+	case apikey != s.PublicKey:
+		writeError(w, http.StatusUnauthorized, "InvalidCredentials", "The passed API key is invalid.")
+	case hash != fmt.Sprintf("%x", md5.Sum([]byte(ts+s.PrivateKey+s.PublicKey))):
+		writeError(w, http.StatusUnauthorized, "InvalidCredentials", "That hash, timestamp and key combination is invalid.")
+	default:
+		return true
+	}
+
+	return false
+}
+
+// route splits an api path into type, id and subtype, ok is false if it is not one of the 39 paths.
+func route(path string) (typ string, id int, subtype string, ok bool) {
+	if !strings.HasPrefix(path, BasePath+"/") {
+		return "", 0, "", false
+	}
+
+	parts := strings.Split(strings.TrimPrefix(path, BasePath+"/"), "/")
+
+	typ = parts[0]
+	if _, ok := subtypes[typ]; !ok {
+		return "", 0, "", false
+	}
+
+	if len(parts) == 1 {
+		return typ, 0, "", true
+	}
+
+	id, err := strconv.Atoi(parts[1])
+	if err != nil || id <= 0 || len(parts) > 3 {
+		return "", 0, "", false
+	}
+
+	if len(parts) == 2 {
+		return typ, id, "", true
+	}
+
+	subtype = parts[2]
+	if !contains(subtypes[typ], subtype) {
+		return "", 0, "", false
+	}
+
+	return typ, id, subtype, true
+}
+
+// related returns resources of subtype linked with parent either way,
+// listed in parent's summaries or listing parent in their own.
+func (s *Server) related(typ string, id int, parent Resource, subtype string) []Resource {
+	ids := make(map[int]bool)
+	for _, ref := range refs(parent, subtype) {
+		ids[ref] = true
+	}
+
+	var resources []Resource
+	for childID, child := range s.data[subtype] {
+		if ids[childID] {
+			resources = append(resources, child)
+			continue
+		}
+
+		for _, ref := range refs(child, typ) {
+			if ref == id {
+				resources = append(resources, child)
+				break
+			}
+		}
+	}
+
+	return resources
+}
+
+// refs returns ids in field of r, either a list of summaries or a single summary like comic.series.
+func refs(r Resource, field string) []int {
+	var summaries []interface{}
+
+	switch v := r[field].(type) {
+	case map[string]interface{}:
+		if items, ok := v["items"].([]interface{}); ok {
+			summaries = items
+		} else {
+			summaries = []interface{}{v}
+		}
+	default:
+		return nil
+	}
+
+	var ids []int
+	for _, summary := range summaries {
+		m, ok := summary.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		uri, _ := m["resourceURI"].(string)
+
+		id, err := strconv.Atoi(uri[strings.LastIndex(uri, "/")+1:])
+		if err == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// writeList filters, orders and pages resources of typ as requested by r.
+func (s *Server) writeList(w http.ResponseWriter, r *http.Request, typ string, resources []Resource, fault *Fault) {
+	q := r.URL.Query()
+
+	limit := 20
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		switch {
+		case err != nil || n < 1:
+			writeError(w, http.StatusConflict, "409", "You must pass an integer limit greater than 0.")
+			return
+		case n > 100:
+			writeError(w, http.StatusConflict, "409", "You may not request more than 100 items.")
+			return
+		}
+		limit = n
+	}
+
+	offset := 0
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusConflict, "409", "You must pass an integer offset greater than or equal to 0.")
+			return
+		}
+		offset = n
+	}
+
+	if v := q.Get("modifiedSince"); v != "" {
+		since, err := parseTime(v)
+		if err != nil {
+			writeError(w, http.StatusConflict, "409", "You must pass a valid date for modifiedSince.")
+			return
+		}
+
+		var modified []Resource
+		for _, res := range resources {
+			v, _ := res["modified"].(string)
+			if t, err := parseTime(v); err == nil && !t.Before(since) {
+				modified = append(modified, res)
+			}
+		}
+		resources = modified
+	}
+
+	fields := []string{}
+	if v := q.Get("orderBy"); v != "" {
+		fields = strings.Split(v, ",")
+		for _, field := range fields {
+			if !contains(orderings[typ], strings.TrimPrefix(field, "-")) {
+				writeError(w, http.StatusConflict, "409", fmt.Sprintf("%s is not a valid ordering parameter.", field))
+				return
+			}
+		}
+	}
+
+	sortResources(resources, fields)
+
+	total := len(resources)
+
+	// repeat the previous page as the real api does at times
+	start := offset
+	if fault != nil && fault.Duplicate && start >= limit {
+		start -= limit
+	}
+
+	end := start + limit
+	if start > total {
+		start = total
+	}
+	if end > total {
+		end = total
+	}
+
+	results := []Resource{}
+	for _, res := range resources[start:end] {
+		if fault == nil || !containsInt(fault.Hide, res.ID()) {
+			results = append(results, res)
+		}
+	}
+
+	data := map[string]interface{}{
+		"offset":  offset,
+		"limit":   limit,
+		"total":   total,
+		"count":   len(results),
+		"results": results,
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "500", err.Error())
+		return
+	}
+
+	etag := fmt.Sprintf("%x", sha1.Sum(b))
+
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Etag", etag)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code":            200,
+		"status":          "Ok",
+		"copyright":       "© 2019 MARVEL",
+		"attributionText": "Data provided by Marvel. © 2019 MARVEL",
+		"attributionHTML": "<a href=\"http://marvel.com\">Data provided by Marvel. © 2019 MARVEL</a>",
+		"etag":            etag,
+		"data":            json.RawMessage(b),
+	})
+}
+
+// sortResources orders resources by fields, each descending if prefixed with "-", then by id.
+func sortResources(resources []Resource, fields []string) {
+	sort.SliceStable(resources, func(i, j int) bool {
+		for _, field := range fields {
+			desc := strings.HasPrefix(field, "-")
+			name := strings.TrimPrefix(field, "-")
+
+			c := compare(orderValue(resources[i], name), orderValue(resources[j], name))
+			if c == 0 {
+				continue
+			}
+
+			return (c < 0) != desc
+		}
+
+		return resources[i].ID() < resources[j].ID()
+	})
+}
+
+// orderValue returns the value of r to order by field.
+func orderValue(r Resource, field string) interface{} {
+	switch field {
+	case "onsaleDate", "focDate":
+		dates, _ := r["dates"].([]interface{})
+		for _, d := range dates {
+			if m, ok := d.(map[string]interface{}); ok && m["type"] == field {
+				return m["date"]
+			}
+		}
+		return nil
+	case "startDate":
+		return r["start"]
+	}
+
+	return r[field]
+}
+
+// compare orders nil first, then numbers, then times and other strings.
+func compare(a, b interface{}) int {
+	switch a := a.(type) {
+	case nil:
+		if b == nil {
+			return 0
+		}
+		return -1
+	case float64:
+		switch b := b.(type) {
+		case nil:
+			return 1
+		case float64:
+			switch {
+			case a < b:
+				return -1
+			case a > b:
+				return 1
+			}
+			return 0
+		}
+		return -1
+	case string:
+		b, ok := b.(string)
+		if !ok {
+			return 1
+		}
+
+		ta, erra := parseTime(a)
+		tb, errb := parseTime(b)
+		if erra == nil && errb == nil {
+			switch {
+			case ta.Before(tb):
+				return -1
+			case ta.After(tb):
+				return 1
+			}
+			return 0
+		}
+
+		return strings.Compare(a, b)
+	}
+
+	return 0
+}
+
+func parseTime(s string) (time.Time, error) {
+	t, err := time.Parse(timeLayout, s)
+	if err == nil {
+		return t, nil
+	}
+
+	return time.Parse("2006-01-02", s)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code":    code,
+		"message": message,
+	})
+}
+
+func contains(ss []string, s string) bool {
+	for _, e := range ss {
+		if e == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsInt(ns []int, n int) bool {
+	for _, v := range ns {
+		if v == n {
+			return true
+		}
+	}
+
+	return false
+}
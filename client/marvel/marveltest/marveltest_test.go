@@ -0,0 +1,161 @@
+package marveltest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/loivis/marvel-comics-api-data-loader/client/marvel"
+)
+
+func TestServer(t *testing.T) {
+	s := NewServer("private", "public")
+	defer s.Close()
+
+	if err := s.LoadFile("comics", "../data/comics.json"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := s.Load("characters", []byte(`[
+		{"id": 1, "name": "b", "modified": "2019-05-02T00:00:00-0400", "comics": {"items": [{"resourceURI": "http://gateway.marvel.com/v1/public/comics/10"}]}},
+		{"id": 2, "name": "a", "modified": "2019-05-01T00:00:00-0400"},
+		{"id": 3, "name": "c", "modified": "2019-05-03T00:00:00-0400", "comics": {"items": [{"resourceURI": "http://gateway.marvel.com/v1/public/comics/10"}]}}
+	]`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c := marvel.NewClient(s.URL+BasePath, "private", "public")
+
+	t.Run("Paging", func(t *testing.T) {
+		var ids []int
+
+		err := (&marvel.Pager{Limit: 30}).Walk(context.Background(), func(ctx context.Context, offset, limit int) (marvel.ListPage, error) {
+			return c.GetComics(ctx, &marvel.Params{Limit: limit, Offset: offset, OrderBy: "-modified"})
+		}, func(page marvel.ListPage) error {
+			ids = append(ids, page.IDs()...)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if got, want := len(ids), 100; got != want {
+			t.Errorf("got %d comics, want %d", got, want)
+		}
+	})
+
+	t.Run("OrderBy", func(t *testing.T) {
+		page, err := c.GetCharacters(context.Background(), &marvel.Params{OrderBy: "name"})
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if got, want := page.IDs(), []int{2, 1, 3}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got ids %v, want %v", got, want)
+		}
+	})
+
+	t.Run("ModifiedSince", func(t *testing.T) {
+		since, err := time.Parse(marvel.TimeLayout, "2019-05-02T00:00:00-0400")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		page, err := c.GetCharacters(context.Background(), &marvel.Params{ModifiedSince: since})
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if got, want := page.IDs(), []int{1, 3}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got ids %v, want %v", got, want)
+		}
+	})
+
+	t.Run("SubResource", func(t *testing.T) {
+		if err := s.Load("comics", []byte(`[{"id": 10}]`)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		page, err := c.GetComicCharacters(context.Background(), 10, &marvel.Params{})
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if got, want := page.IDs(), []int{1, 3}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got ids %v, want %v", got, want)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := c.GetCharacter(context.Background(), 4)
+		checkCode(t, err, http.StatusNotFound)
+	})
+
+	t.Run("InvalidCredentials", func(t *testing.T) {
+		_, err := marvel.NewClient(s.URL+BasePath, "other", "public").GetCharacter(context.Background(), 1)
+		checkCode(t, err, http.StatusUnauthorized)
+	})
+
+	t.Run("Fault", func(t *testing.T) {
+		s.Inject(&Fault{Status: http.StatusTooManyRequests, Times: 1})
+
+		_, err := c.GetCharacter(context.Background(), 1)
+		checkCode(t, err, http.StatusTooManyRequests)
+
+		if _, err := c.GetCharacter(context.Background(), 1); err != nil {
+			t.Errorf("unexpected err after fault: %v", err)
+		}
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		s.Inject(&Fault{Duplicate: true, Times: 1})
+
+		page, err := c.GetCharacters(context.Background(), &marvel.Params{Limit: 1, Offset: 1})
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if got, want := page.IDs(), []int{1}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got ids %v, want %v", got, want)
+		}
+
+		if got, want := page.Offset, 1; got != want {
+			t.Errorf("got offset %d, want %d", got, want)
+		}
+	})
+
+	t.Run("Hide", func(t *testing.T) {
+		s.Inject(&Fault{Hide: []int{1}, Times: 1})
+
+		page, err := c.GetCharacters(context.Background(), &marvel.Params{Limit: 2})
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if got, want := page.IDs(), []int{2}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got ids %v, want %v", got, want)
+		}
+
+		if got, want := page.Total, 3; got != want {
+			t.Errorf("got total %d, want %d", got, want)
+		}
+	})
+}
+
+func checkCode(t *testing.T, err error, code int) {
+	t.Helper()
+
+	var apiErr *marvel.APIError
+	ok := errors.As(err, &apiErr)
+	if !ok {
+		t.Fatalf("got error type %T, want %T", err, apiErr)
+	}
+
+	if got, want := apiErr.Code, code; got != want {
+		t.Errorf("got code %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,247 @@
+package marvel
+
+import (
+	"encoding/json"
+)
+
+// Page contains the envelope of a list response.
+// AttributionText or AttributionHTML must be displayed along with any data shown to users.
+type Page struct {
+	AttributionHTML string
+	AttributionText string
+	Copyright       string
+	Count           int // number of results in this page
+	Etag            string
+	Limit           int
+	Offset          int
+	Total           int // number of resources matching the request
+}
+
+// CharacterPage .
+type CharacterPage struct {
+	Page
+	Results []*Character
+}
+
+// ComicPage .
+type ComicPage struct {
+	Page
+	Results []*Comic
+}
+
+// CreatorPage .
+type CreatorPage struct {
+	Page
+	Results []*Creator
+}
+
+// EventPage .
+type EventPage struct {
+	Page
+	Results []*Event
+}
+
+// SeriesPage .
+type SeriesPage struct {
+	Page
+	Results []*Series
+}
+
+// StoryPage .
+type StoryPage struct {
+	Page
+	Results []*Story
+}
+
+// IDs returns ids of results in the page.
+func (p *CharacterPage) IDs() []int {
+	ids := make([]int, len(p.Results))
+	for i, char := range p.Results {
+		ids[i] = int(char.ID)
+	}
+
+	return ids
+}
+
+// Envelope returns the envelope of the page.
+func (p *CharacterPage) Envelope() *Page {
+	return &p.Page
+}
+
+func (p *CharacterPage) filter(keep func(id int) bool) {
+	var results []*Character
+	for _, char := range p.Results {
+		if keep(int(char.ID)) {
+			results = append(results, char)
+		}
+	}
+
+	p.Results = results
+}
+
+// IDs returns ids of results in the page.
+func (p *ComicPage) IDs() []int {
+	ids := make([]int, len(p.Results))
+	for i, comic := range p.Results {
+		ids[i] = int(comic.ID)
+	}
+
+	return ids
+}
+
+// Envelope returns the envelope of the page.
+func (p *ComicPage) Envelope() *Page {
+	return &p.Page
+}
+
+func (p *ComicPage) filter(keep func(id int) bool) {
+	var results []*Comic
+	for _, comic := range p.Results {
+		if keep(int(comic.ID)) {
+			results = append(results, comic)
+		}
+	}
+
+	p.Results = results
+}
+
+// IDs returns ids of results in the page.
+func (p *CreatorPage) IDs() []int {
+	ids := make([]int, len(p.Results))
+	for i, creator := range p.Results {
+		ids[i] = int(creator.ID)
+	}
+
+	return ids
+}
+
+// Envelope returns the envelope of the page.
+func (p *CreatorPage) Envelope() *Page {
+	return &p.Page
+}
+
+func (p *CreatorPage) filter(keep func(id int) bool) {
+	var results []*Creator
+	for _, creator := range p.Results {
+		if keep(int(creator.ID)) {
+			results = append(results, creator)
+		}
+	}
+
+	p.Results = results
+}
+
+// IDs returns ids of results in the page.
+func (p *EventPage) IDs() []int {
+	ids := make([]int, len(p.Results))
+	for i, event := range p.Results {
+		ids[i] = int(event.ID)
+	}
+
+	return ids
+}
+
+// Envelope returns the envelope of the page.
+func (p *EventPage) Envelope() *Page {
+	return &p.Page
+}
+
+func (p *EventPage) filter(keep func(id int) bool) {
+	var results []*Event
+	for _, event := range p.Results {
+		if keep(int(event.ID)) {
+			results = append(results, event)
+		}
+	}
+
+	p.Results = results
+}
+
+// IDs returns ids of results in the page.
+func (p *SeriesPage) IDs() []int {
+	ids := make([]int, len(p.Results))
+	for i, s := range p.Results {
+		ids[i] = int(s.ID)
+	}
+
+	return ids
+}
+
+// Envelope returns the envelope of the page.
+func (p *SeriesPage) Envelope() *Page {
+	return &p.Page
+}
+
+func (p *SeriesPage) filter(keep func(id int) bool) {
+	var results []*Series
+	for _, s := range p.Results {
+		if keep(int(s.ID)) {
+			results = append(results, s)
+		}
+	}
+
+	p.Results = results
+}
+
+// IDs returns ids of results in the page.
+func (p *StoryPage) IDs() []int {
+	ids := make([]int, len(p.Results))
+	for i, story := range p.Results {
+		ids[i] = int(story.ID)
+	}
+
+	return ids
+}
+
+// Envelope returns the envelope of the page.
+func (p *StoryPage) Envelope() *Page {
+	return &p.Page
+}
+
+func (p *StoryPage) filter(keep func(id int) bool) {
+	var results []*Story
+	for _, story := range p.Results {
+		if keep(int(story.ID)) {
+			results = append(results, story)
+		}
+	}
+
+	p.Results = results
+}
+
+// decodePage decodes envelope of a list response into page and data.results into results.
+func decodePage(b []byte, page *Page, results interface{}) error {
+	var wrapper struct {
+		AttributionHTML string
+		AttributionText string
+		Copyright       string
+		Etag            string
+		Data            struct {
+			Count   int
+			Limit   int
+			Offset  int
+			Results interface{}
+			Total   int
+		}
+	}
+
+	wrapper.Data.Results = results
+
+	err := json.Unmarshal(b, &wrapper)
+	if err != nil {
+		return err
+	}
+
+	*page = Page{
+		AttributionHTML: wrapper.AttributionHTML,
+		AttributionText: wrapper.AttributionText,
+		Copyright:       wrapper.Copyright,
+		Count:           wrapper.Data.Count,
+		Etag:            wrapper.Etag,
+		Limit:           wrapper.Data.Limit,
+		Offset:          wrapper.Data.Offset,
+		Total:           wrapper.Data.Total,
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package marvel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_GetComicsPage(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"code": 200,
+			"status": "Ok",
+			"copyright": "© 2019 MARVEL",
+			"attributionText": "Data provided by Marvel. © 2019 MARVEL",
+			"attributionHTML": "<a href=\"http://marvel.com\">Data provided by Marvel. © 2019 MARVEL</a>",
+			"etag": "foo",
+			"data": {"offset": 20, "limit": 10, "total": 44228, "count": 2, "results": [{"id": 1}, {"id": 2}]}
+		}`))
+	}))
+
+	c := NewClient(ts.URL, "", "")
+
+	page, err := c.GetComics(context.Background(), &Params{Limit: 10, Offset: 20})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	want := Page{
+		AttributionHTML: `<a href="http://marvel.com">Data provided by Marvel. © 2019 MARVEL</a>`,
+		AttributionText: "Data provided by Marvel. © 2019 MARVEL",
+		Copyright:       "© 2019 MARVEL",
+		Count:           2,
+		Etag:            "foo",
+		Limit:           10,
+		Offset:          20,
+		Total:           44228,
+	}
+
+	if got := page.Page; got != want {
+		t.Errorf("got page %+v, want %+v", got, want)
+	}
+
+	if got, want := len(page.Results), 2; got != want {
+		t.Fatalf("got %d comics, want %d", got, want)
+	}
+
+	if got, want := page.Results[1].ID, Int(2); got != want {
+		t.Errorf("got comics[1].ID %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,191 @@
+package marvel
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/avast/retry-go"
+	"golang.org/x/sync/errgroup"
+)
+
+// ListPage is a page returned from any list call,
+// one of *CharacterPage, *ComicPage, *CreatorPage, *EventPage, *SeriesPage or *StoryPage.
+type ListPage interface {
+	// IDs returns ids of results in the page.
+	IDs() []int
+	// Envelope returns the envelope of the page.
+	Envelope() *Page
+
+	filter(keep func(id int) bool)
+}
+
+// PageFunc fetches the page at offset with limit, usually by calling one of the list methods of Client.
+type PageFunc func(ctx context.Context, offset, limit int) (ListPage, error)
+
+// Pager walks all pages of a collection or sub-resource.
+type Pager struct {
+	Attempts    uint                                // tries per page, 1 if not set
+	Concurrency int                                 // pages fetched at once, 1 if not set
+	Limit       int                                 // page size, 100 if not set
+	Offset      int                                 // offset of the first page
+	OnDuplicate func(id int)                        // called with each result already returned in a previous page if set
+	OnRetry     func(offset int, n uint, err error) // called before each retry if set
+	RetryIf     func(offset int, err error) bool    // whether to retry a failed page, all errors are retried if nil
+	Skip        func(offset int) bool               // whether to skip the page at offset after the first one, none are skipped if nil
+	Timeout     time.Duration                       // deadline of each try if set
+}
+
+// Walk fetches all pages starting at Pager.Offset and calls fn with each of them.
+// Results already returned in a previous page, or without id, are removed before fn is called.
+// Calls to fn never overlap, and are made in order of offset if Concurrency is 1.
+// Walk stops at the first error from fetching or from fn.
+func (pg *Pager) Walk(ctx context.Context, fetch PageFunc, fn func(ListPage) error) error {
+	first, err := pg.Fetch(ctx, fetch, pg.Offset)
+	if err != nil {
+		return err
+	}
+
+	return pg.WalkFrom(ctx, first, fetch, fn)
+}
+
+// WalkFrom is like Walk but starts from first, a page already fetched with Pager.Fetch.
+func (pg *Pager) WalkFrom(ctx context.Context, first ListPage, fetch PageFunc, fn func(ListPage) error) error {
+	var mu sync.Mutex
+	seen := make(map[int]bool)
+
+	emit := func(page ListPage) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		page.filter(func(id int) bool {
+			// ids are decoded leniently, so a null or malformed id is 0 and cannot be kept
+			if id == 0 {
+				return false
+			}
+
+			if seen[id] {
+				if pg.OnDuplicate != nil {
+					pg.OnDuplicate(id)
+				}
+				return false
+			}
+			seen[id] = true
+			return true
+		})
+
+		return fn(page)
+	}
+
+	if err := emit(first); err != nil {
+		return err
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+
+	conCh := make(chan struct{}, pg.concurrency())
+
+	envelope := first.Envelope()
+
+loop:
+	for offset := envelope.Offset + pg.limit(); offset < envelope.Total; offset += pg.limit() {
+		if pg.Skip != nil && pg.Skip(offset) {
+			continue
+		}
+
+		select {
+		case conCh <- struct{}{}:
+		case <-gctx.Done():
+			break loop
+		}
+
+		offset := offset
+
+		g.Go(func() error {
+			defer func() {
+				<-conCh
+			}()
+
+			page, err := pg.Fetch(gctx, fetch, offset)
+			if err != nil {
+				return err
+			}
+
+			return emit(page)
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	return ctx.Err()
+}
+
+// Fetch fetches the page at offset, retrying as configured in Pager.
+func (pg *Pager) Fetch(ctx context.Context, fetch PageFunc, offset int) (ListPage, error) {
+	var page ListPage
+
+	err := retry.Do(
+		func() error {
+			ctx := ctx
+			if pg.Timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, pg.Timeout)
+				defer cancel()
+			}
+
+			var err error
+			page, err = fetch(ctx, offset, pg.limit())
+			return err
+		},
+		retry.Attempts(pg.attempts()),
+		retry.LastErrorOnly(true),
+		retry.OnRetry(func(n uint, err error) {
+			if pg.OnRetry != nil {
+				pg.OnRetry(offset, n, err)
+			}
+		}),
+		retry.RetryIf(func(err error) bool {
+			if ctx.Err() != nil {
+				return false
+			}
+
+			if pg.RetryIf == nil {
+				return true
+			}
+
+			return pg.RetryIf(offset, err)
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching page with limit %d offset %d: %w", pg.limit(), offset, err)
+	}
+
+	return page, nil
+}
+
+func (pg *Pager) attempts() uint {
+	if pg.Attempts == 0 {
+		return 1
+	}
+
+	return pg.Attempts
+}
+
+func (pg *Pager) concurrency() int {
+	if pg.Concurrency <= 0 {
+		return 1
+	}
+
+	return pg.Concurrency
+}
+
+func (pg *Pager) limit() int {
+	if pg.Limit <= 0 {
+		return 100
+	}
+
+	return pg.Limit
+}
@@ -0,0 +1,182 @@
+package marvel
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPager_Walk(t *testing.T) {
+	// ids of total 6 comics, id 2 is repeated at offset 2 as the api sometimes does,
+	// and one comic at offset 4 comes without id
+	pages := map[int][]int{0: {1, 2}, 2: {2, 3}, 4: {0, 5}}
+
+	fetch := func(failures int) (PageFunc, map[int]int) {
+		var mu sync.Mutex
+		calls := make(map[int]int)
+
+		return func(ctx context.Context, offset, limit int) (ListPage, error) {
+			mu.Lock()
+			calls[offset]++
+			n := calls[offset]
+			mu.Unlock()
+
+			if n <= failures {
+				return nil, errors.New("foo")
+			}
+
+			page := &ComicPage{Page: Page{Limit: limit, Offset: offset, Total: 6}}
+			for _, id := range pages[offset] {
+				page.Results = append(page.Results, &Comic{ID: Int(id)})
+			}
+
+			return page, nil
+		}, calls
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		f, _ := fetch(0)
+
+		var ids, duplicates []int
+
+		pager := &Pager{Limit: 2, OnDuplicate: func(id int) { duplicates = append(duplicates, id) }}
+
+		err := pager.Walk(context.Background(), f, func(page ListPage) error {
+			ids = append(ids, page.IDs()...)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if got, want := ids, []int{1, 2, 3, 5}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got ids %v, want %v", got, want)
+		}
+
+		if got, want := duplicates, []int{2}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got duplicates %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		f, _ := fetch(0)
+
+		var ids []int
+
+		err := (&Pager{Limit: 2, Concurrency: 3}).Walk(context.Background(), f, func(page ListPage) error {
+			ids = append(ids, page.IDs()...)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		sort.Ints(ids)
+
+		if got, want := ids, []int{1, 2, 3, 5}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got ids %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Offset", func(t *testing.T) {
+		f, calls := fetch(0)
+
+		err := (&Pager{Limit: 2, Offset: 2}).Walk(context.Background(), f, func(page ListPage) error { return nil })
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if got, want := calls, map[int]int{2: 1, 4: 1}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got calls %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Skip", func(t *testing.T) {
+		f, calls := fetch(0)
+
+		err := (&Pager{Limit: 2, Skip: func(offset int) bool { return offset == 2 }}).Walk(context.Background(), f, func(page ListPage) error { return nil })
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if got, want := calls, map[int]int{0: 1, 4: 1}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got calls %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		f, calls := fetch(1)
+
+		err := (&Pager{Limit: 2, Attempts: 2}).Walk(context.Background(), f, func(page ListPage) error { return nil })
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if got, want := calls, map[int]int{0: 2, 2: 2, 4: 2}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got calls %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		f, calls := fetch(1)
+
+		err := (&Pager{Limit: 2, Attempts: 2, RetryIf: func(int, error) bool { return false }}).Walk(context.Background(), f, func(page ListPage) error { return nil })
+		if err == nil {
+			t.Fatal("error is nil")
+		}
+
+		if got, want := calls, map[int]int{0: 1}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got calls %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		f, calls := fetch(0)
+
+		var mu sync.Mutex
+		timedOut := make(map[int]bool)
+
+		// the first try of each page never returns before its deadline
+		slow := func(ctx context.Context, offset, limit int) (ListPage, error) {
+			mu.Lock()
+			first := !timedOut[offset]
+			timedOut[offset] = true
+			mu.Unlock()
+
+			if first {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+
+			return f(ctx, offset, limit)
+		}
+
+		err := (&Pager{Limit: 2, Attempts: 2, Timeout: 10 * time.Millisecond}).Walk(context.Background(), slow, func(page ListPage) error { return nil })
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if got, want := calls, map[int]int{0: 1, 2: 1, 4: 1}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got calls %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		f, _ := fetch(0)
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		err := (&Pager{Limit: 2}).Walk(ctx, f, func(page ListPage) error {
+			cancel()
+			return nil
+		})
+
+		if got, want := err, context.Canceled; got != want {
+			t.Errorf("got error %v, want %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,262 @@
+package marvel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Quota counts api calls of each key per utc day against a daily budget.
+// Usage is kept in memory and saved into Store at most once per Interval, when the budget is used up and on Flush.
+// Calls made since the last save are not counted after a crash, so Flush should be called before exiting.
+type Quota struct {
+	Budget   int           // calls allowed per key and utc day
+	Interval time.Duration // least time between saves of usage, 10 seconds if 0
+	Store    QuotaStore    // persistence of usage, kept in memory if nil
+	Wait     bool          // pause until next utc day when budget is used up, instead of refusing calls
+
+	mu    sync.Mutex
+	used  map[string]*QuotaUsage // map of key to usage of current day
+	dirty map[string]bool        // keys with usage not saved yet
+	saved time.Time              // time of last save
+	now   func() time.Time
+}
+
+// defaultQuotaInterval is the least time between saves of usage if Quota.Interval is 0.
+const defaultQuotaInterval = 10 * time.Second
+
+// QuotaUsage is the count of calls made with one key on one utc day.
+type QuotaUsage struct {
+	Day  string `json:"day"` // formatted as 2006-01-02
+	Used int    `json:"used"`
+}
+
+// QuotaStore persists usage of each key.
+type QuotaStore interface {
+	// Load returns the latest usage of key, nil if none.
+	Load(key string) (*QuotaUsage, error)
+	// Save persists usage of key.
+	Save(key string, usage *QuotaUsage) error
+}
+
+// acquire counts one call of key, returning *QuotaError if the budget is used up
+// or waiting for the next utc day if Quota.Wait is set.
+func (q *Quota) acquire(ctx context.Context, key string) error {
+	for {
+		err := q.take(key)
+
+		qe, ok := err.(*QuotaError)
+		if !ok || !q.Wait {
+			return err
+		}
+
+		timer := time.NewTimer(qe.Reset.Sub(q.clock()))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (q *Quota) take(key string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	usage, err := q.usage(key)
+	if err != nil {
+		return err
+	}
+
+	if usage.Used >= q.Budget {
+		if err := q.save(); err != nil {
+			return err
+		}
+
+		return &QuotaError{Key: key, Used: usage.Used, Budget: q.Budget, Reset: nextDay(q.clock())}
+	}
+
+	usage.Used++
+
+	if q.dirty == nil {
+		q.dirty = make(map[string]bool)
+	}
+	q.dirty[key] = true
+
+	interval := q.Interval
+	if interval == 0 {
+		interval = defaultQuotaInterval
+	}
+
+	if q.clock().Sub(q.saved) < interval {
+		return nil
+	}
+
+	return q.save()
+}
+
+// Flush saves usage not saved yet into Quota.Store.
+func (q *Quota) Flush() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.save()
+}
+
+// save saves usage of keys counted since the last save.
+func (q *Quota) save() error {
+	if q.Store == nil {
+		return nil
+	}
+
+	for key := range q.dirty {
+		if err := q.Store.Save(key, q.used[key]); err != nil {
+			return fmt.Errorf("error saving quota usage: %v", err)
+		}
+
+		delete(q.dirty, key)
+	}
+
+	q.saved = q.clock()
+
+	return nil
+}
+
+// Remaining returns calls left today for key.
+func (q *Quota) Remaining(key string) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	usage, err := q.usage(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if usage.Used >= q.Budget {
+		return 0, nil
+	}
+
+	return q.Budget - usage.Used, nil
+}
+
+// usage returns usage of key for current utc day, loading it from store on first use.
+func (q *Quota) usage(key string) (*QuotaUsage, error) {
+	if q.used == nil {
+		q.used = make(map[string]*QuotaUsage)
+	}
+
+	day := q.clock().UTC().Format("2006-01-02")
+
+	usage, ok := q.used[key]
+	if !ok && q.Store != nil {
+		var err error
+		usage, err = q.Store.Load(key)
+		if err != nil {
+			return nil, fmt.Errorf("error loading quota usage: %v", err)
+		}
+	}
+
+	if usage == nil || usage.Day != day {
+		usage = &QuotaUsage{Day: day}
+	}
+
+	q.used[key] = usage
+
+	return usage, nil
+}
+
+func (q *Quota) clock() time.Time {
+	if q.now == nil {
+		return time.Now()
+	}
+
+	return q.now()
+}
+
+func nextDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+}
+
+// FileQuotaStore is a QuotaStore kept in one json file.
+type FileQuotaStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileQuotaStore returns a FileQuotaStore kept in path.
+func NewFileQuotaStore(path string) *FileQuotaStore {
+	return &FileQuotaStore{path: path}
+}
+
+// Load returns the latest usage of key, nil if none.
+func (fs *FileQuotaStore) Load(key string) (*QuotaUsage, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	m, err := fs.read()
+	if err != nil {
+		return nil, err
+	}
+
+	return m[key], nil
+}
+
+// Save persists usage of key.
+func (fs *FileQuotaStore) Save(key string, usage *QuotaUsage) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	m, err := fs.read()
+	if err != nil {
+		return err
+	}
+
+	m[key] = usage
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(fs.path), ".quota-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), fs.path)
+}
+
+func (fs *FileQuotaStore) read() (map[string]*QuotaUsage, error) {
+	m := make(map[string]*QuotaUsage)
+
+	b, err := ioutil.ReadFile(fs.path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("error decoding %q: %v", fs.path, err)
+	}
+
+	return m, nil
+}
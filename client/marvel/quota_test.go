@@ -0,0 +1,236 @@
+package marvel
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQuota_Acquire(t *testing.T) {
+	t.Run("Budget", func(t *testing.T) {
+		now := time.Date(2019, 5, 1, 23, 0, 0, 0, time.UTC)
+		q := &Quota{Budget: 2, now: func() time.Time { return now }}
+
+		for i := 0; i < 2; i++ {
+			if err := q.acquire(context.Background(), "foo"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		err := q.acquire(context.Background(), "foo")
+
+		qe, ok := err.(*QuotaError)
+		if !ok {
+			t.Fatalf("got error type %T, want %T", err, qe)
+		}
+
+		if got, want := qe.Reset, time.Date(2019, 5, 2, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+			t.Errorf("got reset %v, want %v", got, want)
+		}
+
+		if err := q.acquire(context.Background(), "bar"); err != nil {
+			t.Errorf("unexpected error for other key: %v", err)
+		}
+
+		now = now.Add(time.Hour)
+
+		if err := q.acquire(context.Background(), "foo"); err != nil {
+			t.Errorf("unexpected error on next day: %v", err)
+		}
+	})
+
+	t.Run("WaitCancelled", func(t *testing.T) {
+		q := &Quota{Budget: 0, Wait: true}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		if got, want := q.acquire(ctx, "foo"), context.DeadlineExceeded; got != want {
+			t.Errorf("got error %v, want %v", got, want)
+		}
+	})
+}
+
+func TestQuota_Remaining(t *testing.T) {
+	dir, err := ioutil.TempDir("", "marvel-quota")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewFileQuotaStore(filepath.Join(dir, "quota.json"))
+
+	q := &Quota{Budget: 5, Store: store}
+	for i := 0; i < 3; i++ {
+		if err := q.acquire(context.Background(), "foo"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := q.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// usage survives restarts through store
+	q = &Quota{Budget: 5, Store: store}
+
+	remaining, err := q.Remaining("foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := remaining, 2; got != want {
+		t.Errorf("got remaining %d, want %d", got, want)
+	}
+}
+
+func TestQuota_Save(t *testing.T) {
+	now := time.Date(2019, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := &countingQuotaStore{}
+	q := &Quota{Budget: 4, Interval: time.Minute, Store: store, now: func() time.Time { return now }}
+
+	acquire := func(n int) {
+		for i := 0; i < n; i++ {
+			q.acquire(context.Background(), "foo")
+		}
+	}
+
+	for _, tc := range []struct {
+		desc  string
+		run   func()
+		saves int
+		used  int
+	}{
+		{desc: "First", run: func() { acquire(1) }, saves: 1, used: 1},
+		{desc: "WithinInterval", run: func() { acquire(2) }, saves: 1, used: 1},
+		{desc: "AfterInterval", run: func() { now = now.Add(time.Minute); acquire(1) }, saves: 2, used: 4},
+		{desc: "Exhausted", run: func() { acquire(1) }, saves: 2, used: 4},
+		{desc: "Flush", run: func() { q.Flush() }, saves: 2, used: 4},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			tc.run()
+
+			if got, want := store.saves, tc.saves; got != want {
+				t.Errorf("got %d saves, want %d", got, want)
+			}
+
+			if got, want := store.used, tc.used; got != want {
+				t.Errorf("got %d used saved, want %d", got, want)
+			}
+		})
+	}
+
+	t.Run("ExhaustedUnsaved", func(t *testing.T) {
+		store := &countingQuotaStore{}
+		q := &Quota{Budget: 2, Interval: time.Minute, Store: store, now: func() time.Time { return now }}
+
+		for i := 0; i < 3; i++ {
+			q.acquire(context.Background(), "foo")
+		}
+
+		// the refused call saves usage counted within the interval
+		if got, want := store.used, 2; got != want {
+			t.Errorf("got %d used saved, want %d", got, want)
+		}
+	})
+
+	t.Run("FlushUnsaved", func(t *testing.T) {
+		store := &countingQuotaStore{}
+		q := &Quota{Budget: 5, Interval: time.Minute, Store: store, now: func() time.Time { return now }}
+
+		for i := 0; i < 3; i++ {
+			q.acquire(context.Background(), "foo")
+		}
+
+		if err := q.Flush(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got, want := store.used, 3; got != want {
+			t.Errorf("got %d used saved, want %d", got, want)
+		}
+	})
+}
+
+// countingQuotaStore keeps the latest usage saved and counts saves.
+type countingQuotaStore struct {
+	saves int
+	used  int
+}
+
+func (s *countingQuotaStore) Load(key string) (*QuotaUsage, error) {
+	return nil, nil
+}
+
+func (s *countingQuotaStore) Save(key string, usage *QuotaUsage) error {
+	s.saves++
+	s.used = usage.Used
+
+	return nil
+}
+
+func TestClient_GetWithQuota(t *testing.T) {
+	var calls int
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{}`))
+	}))
+
+	c := NewClient(ts.URL, "", "public", WithQuota(&Quota{Budget: 1}))
+
+	if _, err := c.get(context.Background(), &Params{typ: "foo"}); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if _, err := c.get(context.Background(), &Params{typ: "foo"}); err == nil {
+		t.Fatal("error is nil")
+	}
+
+	if got, want := calls, 1; got != want {
+		t.Errorf("got %d calls, want %d", got, want)
+	}
+
+	remaining, ok := c.Remaining()
+	if !ok {
+		t.Fatal("got no quota")
+	}
+
+	if got, want := remaining, 0; got != want {
+		t.Errorf("got remaining %d, want %d", got, want)
+	}
+}
+
+func TestClient_GetWithQuotaWait(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	// next utc day starts 50ms after the first call
+	started := time.Now()
+	beforeMidnight := nextDay(started).Add(-50 * time.Millisecond)
+	now := func() time.Time { return beforeMidnight.Add(time.Since(started)) }
+
+	c := NewClient(ts.URL, "", "public", WithQuota(&Quota{Budget: 1, Wait: true, now: now}))
+
+	if _, err := c.get(context.Background(), &Params{typ: "foo"}); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	params := &Params{typ: "foo"}
+	params.SetTimeout(10 * time.Millisecond)
+
+	if _, err := c.get(context.Background(), params); err != nil {
+		t.Fatalf("unexpected err waiting for quota beyond timeout of call: %v", err)
+	}
+
+	if got, want := time.Since(started), 50*time.Millisecond; got < want {
+		t.Errorf("got call after %v, want after %v", got, want)
+	}
+}
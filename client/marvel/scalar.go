@@ -0,0 +1,145 @@
+package marvel
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+)
+
+// String is a string decoded leniently.
+// Numbers and booleans are kept as their json text, null and other values decode to "".
+type String string
+
+// Int is an int decoded leniently.
+// Fractions are truncated, numeric strings are parsed, null and other values decode to 0.
+type Int int
+
+// Float is a float64 decoded leniently.
+// Numeric strings are parsed, null and other values decode to 0.
+type Float float64
+
+var coercions = struct {
+	sync.Mutex
+	counts map[string]int
+}{counts: make(map[string]int)}
+
+// coerced records one value of json type from decoded into a Go type to.
+func coerced(from, to string) {
+	coercions.Lock()
+	defer coercions.Unlock()
+
+	coercions.counts[from+" to "+to]++
+}
+
+// Coercions returns how many values were decoded leniently so far, keyed like "number to string".
+func Coercions() map[string]int {
+	coercions.Lock()
+	defer coercions.Unlock()
+
+	out := make(map[string]int, len(coercions.counts))
+	for k, v := range coercions.counts {
+		out[k] = v
+	}
+
+	return out
+}
+
+// UnmarshalJSON decodes any json value into String.
+func (s *String) UnmarshalJSON(b []byte) error {
+	switch typ := jsonKind(b); typ {
+	case "string":
+		var v string
+		if err := json.Unmarshal(b, &v); err != nil {
+			return err
+		}
+		*s = String(v)
+	case "number", "boolean":
+		*s = String(b)
+		coerced(typ, "string")
+	default:
+		*s = ""
+		coerced(typ, "string")
+	}
+
+	return nil
+}
+
+// UnmarshalJSON decodes any json value into Int.
+func (i *Int) UnmarshalJSON(b []byte) error {
+	f, typ := parseNumber(b)
+
+	if f != math.Trunc(f) {
+		typ = "fraction"
+	}
+	if typ != "number" {
+		coerced(typ, "int")
+	}
+
+	*i = Int(f)
+
+	return nil
+}
+
+// UnmarshalJSON decodes any json value into Float.
+func (f *Float) UnmarshalJSON(b []byte) error {
+	v, typ := parseNumber(b)
+	if typ != "number" {
+		coerced(typ, "float")
+	}
+
+	*f = Float(v)
+
+	return nil
+}
+
+// parseNumber returns the number in b, parsing strings, along with the json type of b.
+// The type is "invalid string" if b is a string not holding a number.
+func parseNumber(b []byte) (float64, string) {
+	typ := jsonKind(b)
+
+	switch typ {
+	case "number":
+		f, err := strconv.ParseFloat(string(b), 64)
+		if err == nil {
+			return f, typ
+		}
+	case "string":
+		var s string
+		if err := json.Unmarshal(b, &s); err == nil {
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				return f, typ
+			}
+		}
+		return 0, "invalid string"
+	}
+
+	return 0, typ
+}
+
+// jsonKind returns the json type of the value in b, e.g. "string" or "null".
+func jsonKind(b []byte) string {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return "empty"
+	}
+
+	switch c := b[0]; {
+	case c == '"':
+		return "string"
+	case c == '{':
+		return "object"
+	case c == '[':
+		return "array"
+	case c == 't' || c == 'f':
+		return "boolean"
+	case c == 'n':
+		return "null"
+	case c == '-' || (c >= '0' && c <= '9'):
+		return "number"
+	}
+
+	return fmt.Sprintf("%q", b[0])
+}
@@ -0,0 +1,84 @@
+package marvel
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestScalar_UnmarshalJSON(t *testing.T) {
+	for _, tc := range []struct {
+		desc      string
+		b         []byte
+		want      scalars
+		coercions map[string]int
+	}{
+		{
+			desc: "Exact",
+			b:    []byte(`{"s":"foo","i":1,"f":1.5}`),
+			want: scalars{S: "foo", I: 1, F: 1.5},
+		},
+		{
+			desc:      "Number",
+			b:         []byte(`{"s":9780785190219,"i":1.5,"f":1}`),
+			want:      scalars{S: "9780785190219", I: 1, F: 1},
+			coercions: map[string]int{"number to string": 1, "fraction to int": 1},
+		},
+		{
+			desc:      "String",
+			b:         []byte(`{"s":"1","i":"12","f":"2.99"}`),
+			want:      scalars{S: "1", I: 12, F: 2.99},
+			coercions: map[string]int{"string to int": 1, "string to float": 1},
+		},
+		{
+			desc:      "InvalidString",
+			b:         []byte(`{"i":"foo","f":""}`),
+			want:      scalars{},
+			coercions: map[string]int{"invalid string to int": 1, "invalid string to float": 1},
+		},
+		{
+			desc:      "Null",
+			b:         []byte(`{"s":null,"i":null,"f":null}`),
+			want:      scalars{},
+			coercions: map[string]int{"null to string": 1, "null to int": 1, "null to float": 1},
+		},
+		{
+			desc:      "Other",
+			b:         []byte(`{"s":true,"i":[1],"f":{"a":1}}`),
+			want:      scalars{S: "true"},
+			coercions: map[string]int{"boolean to string": 1, "array to int": 1, "object to float": 1},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			before := Coercions()
+
+			var got scalars
+			if err := json.Unmarshal(tc.b, &got); err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			if got != tc.want {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+
+			after := Coercions()
+
+			for k := range after {
+				if got, want := after[k]-before[k], tc.coercions[k]; got != want {
+					t.Errorf("got %d coercions %q, want %d", got, k, want)
+				}
+			}
+
+			for k, want := range tc.coercions {
+				if _, ok := after[k]; !ok {
+					t.Errorf("got no coercion %q, want %d", k, want)
+				}
+			}
+		})
+	}
+}
+
+type scalars struct {
+	S String
+	I Int
+	F Float
+}
@@ -0,0 +1,282 @@
+package marvel
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+/*
+	callers fan out at more than one level, e.g. comics complemented concurrently,
+	each paging over its characters concurrently, so capping goroutines at each level
+	does not cap requests in flight. a Scheduler shared by the client caps them instead.
+	a slot is only held for a single request, never while waiting for other calls,
+	so callers waiting on their own calls cannot deadlock.
+	waiting calls are queued by owner, e.g. the entity they are fetched for,
+	and owners take turns, so one entity with many pages does not starve others.
+
+	an adaptive Scheduler also moves its limit like tcp congestion control does with its window:
+	it grows by one after a limit's worth of fast successful responses, and halves on signs of overload,
+	429, 5xx or timeouts. requests already in flight when it halves were sent at the old limit,
+	so their failures do not halve it again.
+*/
+
+// metrics of the latest adaptive Scheduler, served at /debug/vars with expvar
+var (
+	concurrencyLimit = expvar.NewInt("marvel_api_concurrency")
+	overloads        = expvar.NewInt("marvel_api_overloads")
+)
+
+// Scheduler caps api requests in flight across all callers of a Client.
+type Scheduler struct {
+	mu       sync.Mutex
+	limit    int
+	inFlight int
+	queues   map[string][]chan struct{} // waiting calls of each owner in order of arrival
+	owners   []string                   // owners with waiting calls, in turn
+
+	max       int           // highest limit of an adaptive scheduler, 0 if not adaptive
+	latency   time.Duration // responses slower than latency do not grow the limit
+	successes int           // fast successful responses since the limit last changed
+	observed  int           // responses observed
+	hold      int           // overload of responses observed up to hold does not halve the limit
+}
+
+// NewScheduler returns a Scheduler allowing limit requests in flight, at least one.
+func NewScheduler(limit int) *Scheduler {
+	if limit < 1 {
+		limit = 1
+	}
+
+	return &Scheduler{
+		limit:  limit,
+		queues: make(map[string][]chan struct{}),
+	}
+}
+
+// NewAdaptiveScheduler returns a Scheduler starting at limit requests in flight,
+// growing up to max while responses come faster than latency, and halving on overload.
+func NewAdaptiveScheduler(limit, max int, latency time.Duration) *Scheduler {
+	s := NewScheduler(limit)
+	s.max = max
+	if s.max < s.limit {
+		s.max = s.limit
+	}
+	s.latency = latency
+
+	concurrencyLimit.Set(int64(s.limit))
+
+	return s
+}
+
+// WithScheduler makes Client send requests within slots of s.
+// Sharing s between clients caps requests of them all.
+func WithScheduler(s *Scheduler) Option {
+	return func(c *Client) {
+		c.scheduler = s
+	}
+}
+
+type ownerKey struct{}
+
+// WithOwner returns ctx marking calls made with it as made for owner, e.g. "comics/1308".
+// Owners take turns for free slots of a Scheduler.
+func WithOwner(ctx context.Context, owner string) context.Context {
+	return context.WithValue(ctx, ownerKey{}, owner)
+}
+
+func ownerOf(ctx context.Context) string {
+	owner, _ := ctx.Value(ownerKey{}).(string)
+	return owner
+}
+
+// Limit returns the count of requests allowed in flight.
+func (s *Scheduler) Limit() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.limit
+}
+
+// InFlight returns the count of requests in flight.
+func (s *Scheduler) InFlight() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.inFlight
+}
+
+// Acquire waits for a free slot in turn of the owner of ctx, or until ctx is done.
+// release frees the slot and is safe to call more than once.
+// A nil Scheduler never waits.
+func (s *Scheduler) Acquire(ctx context.Context) (release func(), err error) {
+	if s == nil {
+		return func() {}, nil
+	}
+
+	s.mu.Lock()
+
+	if s.inFlight < s.limit && len(s.owners) == 0 {
+		s.inFlight++
+		s.mu.Unlock()
+
+		return s.releaser(), nil
+	}
+
+	owner := ownerOf(ctx)
+	ready := make(chan struct{})
+
+	if len(s.queues[owner]) == 0 {
+		s.owners = append(s.owners, owner)
+	}
+	s.queues[owner] = append(s.queues[owner], ready)
+
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return s.releaser(), nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.remove(owner, ready) {
+		// granted a slot meanwhile, pass it on
+		s.inFlight--
+		s.dispatch()
+	}
+
+	return nil, ctx.Err()
+}
+
+// Observe adjusts the limit of an adaptive s by a response received in elapsed,
+// and whether it was a sign of overload.
+func (s *Scheduler) Observe(elapsed time.Duration, overloaded bool) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.max == 0 {
+		return
+	}
+
+	s.observed++
+
+	switch {
+	case overloaded:
+		overloads.Add(1)
+
+		if s.observed <= s.hold {
+			return
+		}
+
+		s.limit /= 2
+		if s.limit < 1 {
+			s.limit = 1
+		}
+		s.hold = s.observed + s.inFlight
+		s.successes = 0
+
+		concurrencyLimit.Set(int64(s.limit))
+		log.Warn().Int("limit", s.limit).Msg("api overloaded, decreased concurrency")
+	case elapsed <= s.latency:
+		s.successes++
+		if s.successes < s.limit || s.limit >= s.max {
+			return
+		}
+
+		s.limit++
+		s.successes = 0
+
+		concurrencyLimit.Set(int64(s.limit))
+		log.Info().Int("limit", s.limit).Msg("increased concurrency")
+
+		s.dispatch()
+	}
+}
+
+// overloaded returns whether a response with code, or err failing the request, is a sign of overload.
+func overloaded(code int, err error) bool {
+	if err != nil {
+		var ne net.Error
+		return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &ne) && ne.Timeout()
+	}
+
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// releaser returns a func freeing one slot once.
+func (s *Scheduler) releaser() func() {
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			s.inFlight--
+			s.dispatch()
+		})
+	}
+}
+
+// dispatch grants free slots to waiting calls, one of each owner in turn.
+func (s *Scheduler) dispatch() {
+	for s.inFlight < s.limit && len(s.owners) > 0 {
+		owner := s.owners[0]
+		s.owners = s.owners[1:]
+
+		queue := s.queues[owner]
+		ready := queue[0]
+
+		if len(queue) > 1 {
+			s.queues[owner] = queue[1:]
+			s.owners = append(s.owners, owner)
+		} else {
+			delete(s.queues, owner)
+		}
+
+		s.inFlight++
+		close(ready)
+	}
+}
+
+// remove removes ready from waiting calls of owner, and returns whether it was waiting.
+func (s *Scheduler) remove(owner string, ready chan struct{}) bool {
+	queue := s.queues[owner]
+
+	for i, ch := range queue {
+		if ch != ready {
+			continue
+		}
+
+		queue = append(queue[:i], queue[i+1:]...)
+		if len(queue) > 0 {
+			s.queues[owner] = queue
+			return true
+		}
+
+		delete(s.queues, owner)
+		for j, o := range s.owners {
+			if o == owner {
+				s.owners = append(s.owners[:j], s.owners[j+1:]...)
+				break
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
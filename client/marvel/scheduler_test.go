@@ -0,0 +1,214 @@
+package marvel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScheduler_Acquire(t *testing.T) {
+	// waiting returns once owner has n calls queued
+	waiting := func(s *Scheduler, owner string, n int) {
+		for {
+			s.mu.Lock()
+			queued := len(s.queues[owner])
+			s.mu.Unlock()
+
+			if queued == n {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	t.Run("Turns", func(t *testing.T) {
+		s := NewScheduler(1)
+
+		release, err := s.Acquire(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		var mu sync.Mutex
+		var order []string
+		var wg sync.WaitGroup
+
+		acquire := func(owner string) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				release, err := s.Acquire(WithOwner(context.Background(), owner))
+				if err != nil {
+					t.Errorf("unexpected err: %v", err)
+					return
+				}
+
+				mu.Lock()
+				order = append(order, owner)
+				mu.Unlock()
+
+				release()
+			}()
+		}
+
+		for i := 1; i <= 3; i++ {
+			acquire("a")
+			waiting(s, "a", i)
+		}
+		acquire("b")
+		waiting(s, "b", 1)
+
+		release()
+		wg.Wait()
+
+		if got, want := order, []string{"a", "b", "a", "a"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got order %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		s := NewScheduler(1)
+
+		release, err := s.Acquire(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		if _, err := s.Acquire(ctx); err != context.DeadlineExceeded {
+			t.Errorf("got err %v, want %v", err, context.DeadlineExceeded)
+		}
+
+		release()
+		release()
+
+		if got, want := s.InFlight(), 0; got != want {
+			t.Errorf("got %d in flight, want %d", got, want)
+		}
+
+		if got, want := len(s.owners), 0; got != want {
+			t.Errorf("got %d owners waiting, want %d", got, want)
+		}
+	})
+}
+
+func TestClient_GetWithScheduler(t *testing.T) {
+	var mu sync.Mutex
+	var current, max int
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		current++
+		if current > max {
+			max = current
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		current--
+		mu.Unlock()
+
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	c := NewClient(ts.URL, "", "foo", WithScheduler(NewScheduler(2)))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if _, err := c.get(context.Background(), &Params{typ: "comics"}); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if max > 2 {
+		t.Errorf("got %d requests in flight, want at most 2", max)
+	}
+}
+
+func TestScheduler_Observe(t *testing.T) {
+	t.Run("Adaptive", func(t *testing.T) {
+		s := NewAdaptiveScheduler(2, 4, time.Second)
+
+		limits := func(observe func()) []int {
+			var got []int
+			for i := 0; i < 4; i++ {
+				observe()
+				got = append(got, s.Limit())
+			}
+			return got
+		}
+
+		if got, want := limits(func() { s.Observe(2*time.Second, false) }), []int{2, 2, 2, 2}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got limits %v on slow responses, want %v", got, want)
+		}
+
+		if got, want := limits(func() { s.Observe(time.Millisecond, false) }), []int{2, 3, 3, 3}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got limits %v on fast responses, want %v", got, want)
+		}
+
+		if got, want := limits(func() { s.Observe(time.Millisecond, false) }), []int{4, 4, 4, 4}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got limits %v on fast responses up to max, want %v", got, want)
+		}
+
+		// overload of the two requests in flight when halved does not halve it again
+		for i := 0; i < 2; i++ {
+			if _, err := s.Acquire(context.Background()); err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+		}
+
+		if got, want := limits(func() { s.Observe(time.Millisecond, true) }), []int{2, 2, 2, 1}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got limits %v on overload, want %v", got, want)
+		}
+	})
+
+	t.Run("Fixed", func(t *testing.T) {
+		s := NewScheduler(2)
+
+		for i := 0; i < 4; i++ {
+			s.Observe(time.Millisecond, i%2 == 0)
+		}
+
+		if got, want := s.Limit(), 2; got != want {
+			t.Errorf("got limit %d, want %d", got, want)
+		}
+	})
+}
+
+func TestOverloaded(t *testing.T) {
+	for _, tc := range []struct {
+		desc string
+		code int
+		err  error
+		want bool
+	}{
+		{desc: "OK", code: http.StatusOK},
+		{desc: "NotFound", code: http.StatusNotFound},
+		{desc: "TooManyRequests", code: http.StatusTooManyRequests, want: true},
+		{desc: "BadGateway", code: http.StatusBadGateway, want: true},
+		{desc: "Timeout", err: context.DeadlineExceeded, want: true},
+		{desc: "Canceled", err: context.Canceled},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := overloaded(tc.code, tc.err); got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -33,8 +33,8 @@ func (c *Client) GetSeriesSingle(ctx context.Context, id int) (*Series, error) {
 	return data.Data.Results[0], nil
 }
 
-// GetSeries returns list of series with given Params.
-func (c *Client) GetSeries(ctx context.Context, params *Params) ([]*Series, error) {
+// GetSeries returns a page of series with given Params.
+func (c *Client) GetSeries(ctx context.Context, params *Params) (*SeriesPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -44,8 +44,8 @@ func (c *Client) GetSeries(ctx context.Context, params *Params) ([]*Series, erro
 	return c.getSeries(ctx, params)
 }
 
-// GetCharacterSeries returns list of series filtered by character ID.
-func (c *Client) GetCharacterSeries(ctx context.Context, id int, params *Params) ([]*Series, error) {
+// GetCharacterSeries returns a page of series filtered by character ID.
+func (c *Client) GetCharacterSeries(ctx context.Context, id int, params *Params) (*SeriesPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -57,8 +57,8 @@ func (c *Client) GetCharacterSeries(ctx context.Context, id int, params *Params)
 	return c.getSeries(ctx, params)
 }
 
-// GetComicSeries returns list of series filtered by comic ID.
-func (c *Client) GetComicSeries(ctx context.Context, id int, params *Params) ([]*Series, error) {
+// GetComicSeries returns a page of series filtered by comic ID.
+func (c *Client) GetComicSeries(ctx context.Context, id int, params *Params) (*SeriesPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -70,8 +70,8 @@ func (c *Client) GetComicSeries(ctx context.Context, id int, params *Params) ([]
 	return c.getSeries(ctx, params)
 }
 
-// GetCreatorSeries returns list of series filtered by creator ID.
-func (c *Client) GetCreatorSeries(ctx context.Context, id int, params *Params) ([]*Series, error) {
+// GetCreatorSeries returns a page of series filtered by creator ID.
+func (c *Client) GetCreatorSeries(ctx context.Context, id int, params *Params) (*SeriesPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -83,8 +83,8 @@ func (c *Client) GetCreatorSeries(ctx context.Context, id int, params *Params) (
 	return c.getSeries(ctx, params)
 }
 
-// GetEventSeries returns list of series filtered by event ID.
-func (c *Client) GetEventSeries(ctx context.Context, id int, params *Params) ([]*Series, error) {
+// GetEventSeries returns a page of series filtered by event ID.
+func (c *Client) GetEventSeries(ctx context.Context, id int, params *Params) (*SeriesPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -96,8 +96,8 @@ func (c *Client) GetEventSeries(ctx context.Context, id int, params *Params) ([]
 	return c.getSeries(ctx, params)
 }
 
-// GetStorySeries returns list of series filtered by story ID.
-func (c *Client) GetStorySeries(ctx context.Context, id int, params *Params) ([]*Series, error) {
+// GetStorySeries returns a page of series filtered by story ID.
+func (c *Client) GetStorySeries(ctx context.Context, id int, params *Params) (*SeriesPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -109,24 +109,20 @@ func (c *Client) GetStorySeries(ctx context.Context, id int, params *Params) ([]
 	return c.getSeries(ctx, params)
 }
 
-// getSeries returns a list of series with given Params.
+// getSeries returns a page of series with given Params.
 // If Params.subTyp is set, it returns seriess filtered by Params.Type and Params.ID.
-func (c *Client) getSeries(ctx context.Context, params *Params) ([]*Series, error) {
+func (c *Client) getSeries(ctx context.Context, params *Params) (*SeriesPage, error) {
 	resp, err := c.get(ctx, params)
 	if err != nil {
 		return nil, err
 	}
 
-	var data struct {
-		Data struct {
-			Results []*Series
-		}
-	}
+	page := &SeriesPage{}
 
-	err = json.Unmarshal(resp, &data)
+	err = decodePage(resp, &page.Page, &page.Results)
 	if err != nil {
 		return nil, err
 	}
 
-	return data.Data.Results, nil
+	return page, nil
 }
@@ -33,8 +33,8 @@ func (c *Client) GetStory(ctx context.Context, id int) (*Story, error) {
 	return data.Data.Results[0], nil
 }
 
-// GetStories returns list of story with given Params.
-func (c *Client) GetStories(ctx context.Context, params *Params) ([]*Story, error) {
+// GetStories returns a page of story with given Params.
+func (c *Client) GetStories(ctx context.Context, params *Params) (*StoryPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -44,8 +44,8 @@ func (c *Client) GetStories(ctx context.Context, params *Params) ([]*Story, erro
 	return c.getStories(ctx, params)
 }
 
-// GetCharacterStories returns list of story filtered by character ID.
-func (c *Client) GetCharacterStories(ctx context.Context, id int, params *Params) ([]*Story, error) {
+// GetCharacterStories returns a page of story filtered by character ID.
+func (c *Client) GetCharacterStories(ctx context.Context, id int, params *Params) (*StoryPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -57,8 +57,8 @@ func (c *Client) GetCharacterStories(ctx context.Context, id int, params *Params
 	return c.getStories(ctx, params)
 }
 
-// GetComicStories returns list of story filtered by comic ID.
-func (c *Client) GetComicStories(ctx context.Context, id int, params *Params) ([]*Story, error) {
+// GetComicStories returns a page of story filtered by comic ID.
+func (c *Client) GetComicStories(ctx context.Context, id int, params *Params) (*StoryPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -70,8 +70,8 @@ func (c *Client) GetComicStories(ctx context.Context, id int, params *Params) ([
 	return c.getStories(ctx, params)
 }
 
-// GetCreatorStories returns list of story filtered by creator ID.
-func (c *Client) GetCreatorStories(ctx context.Context, id int, params *Params) ([]*Story, error) {
+// GetCreatorStories returns a page of story filtered by creator ID.
+func (c *Client) GetCreatorStories(ctx context.Context, id int, params *Params) (*StoryPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -83,8 +83,8 @@ func (c *Client) GetCreatorStories(ctx context.Context, id int, params *Params)
 	return c.getStories(ctx, params)
 }
 
-// GetEventStories returns list of story filtered by event ID.
-func (c *Client) GetEventStories(ctx context.Context, id int, params *Params) ([]*Story, error) {
+// GetEventStories returns a page of story filtered by event ID.
+func (c *Client) GetEventStories(ctx context.Context, id int, params *Params) (*StoryPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -96,8 +96,8 @@ func (c *Client) GetEventStories(ctx context.Context, id int, params *Params) ([
 	return c.getStories(ctx, params)
 }
 
-// GetSeriesStories returns list of story filtered by series ID.
-func (c *Client) GetSeriesStories(ctx context.Context, id int, params *Params) ([]*Story, error) {
+// GetSeriesStories returns a page of story filtered by series ID.
+func (c *Client) GetSeriesStories(ctx context.Context, id int, params *Params) (*StoryPage, error) {
 	if params == nil {
 		return nil, errors.New("params is nil")
 	}
@@ -109,24 +109,20 @@ func (c *Client) GetSeriesStories(ctx context.Context, id int, params *Params) (
 	return c.getStories(ctx, params)
 }
 
-// getStories returns a list of story with given Params.
+// getStories returns a page of story with given Params.
 // If Params.subTyp is set, it returns stories filtered by Params.Type and Params.ID.
-func (c *Client) getStories(ctx context.Context, params *Params) ([]*Story, error) {
+func (c *Client) getStories(ctx context.Context, params *Params) (*StoryPage, error) {
 	resp, err := c.get(ctx, params)
 	if err != nil {
 		return nil, err
 	}
 
-	var data struct {
-		Data struct {
-			Results []*Story
-		}
-	}
+	page := &StoryPage{}
 
-	err = json.Unmarshal(resp, &data)
+	err = decodePage(resp, &page.Page, &page.Results)
 	if err != nil {
 		return nil, err
 	}
 
-	return data.Data.Results, nil
+	return page, nil
 }
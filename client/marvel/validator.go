@@ -0,0 +1,253 @@
+package marvel
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Validator checks api responses against model definitions of a swagger spec,
+// e.g. swagger/spec-1.0.json or swagger/spec-2.0.json, counting fields not matching their definition.
+type Validator struct {
+	models map[string]map[string]*property // map of model name to its properties
+
+	mu         sync.Mutex
+	mismatches map[Mismatch]int
+}
+
+// Mismatch is a field of an api response not matching its definition.
+type Mismatch struct {
+	Entity string // model of the entity holding the field, e.g. Comic
+	ID     int    // id of the entity
+	Field  string // path of the field in the entity, e.g. series.name
+	Want   string // type defined in spec
+	Got    string // json type in response
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%s %d %s: got %s, want %s", m.Entity, m.ID, m.Field, m.Got, m.Want)
+}
+
+// property is the definition of one field, normalized across spec versions.
+type property struct {
+	kind string // one of array, integer, number, object and string
+	ref  string // model of object, or of array items
+}
+
+// NewValidator returns a Validator with models defined in the swagger spec at path.
+// Both swagger 1.2 "models" and swagger 2.0 "definitions" are supported.
+func NewValidator(path string) (*Validator, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	type spec struct {
+		Type  string
+		Ref   string `json:"$ref"`
+		Items *struct {
+			Type string
+			Ref  string `json:"$ref"`
+		}
+	}
+
+	var swagger struct {
+		Models      map[string]struct{ Properties map[string]spec } // 1.2
+		Definitions map[string]struct{ Properties map[string]spec } // 2.0
+	}
+
+	if err := json.Unmarshal(b, &swagger); err != nil {
+		return nil, fmt.Errorf("error decoding spec %q: %v", path, err)
+	}
+
+	defs := swagger.Models
+	if len(defs) == 0 {
+		defs = swagger.Definitions
+	}
+
+	if len(defs) == 0 {
+		return nil, fmt.Errorf("no model defined in spec %q", path)
+	}
+
+	v := &Validator{
+		models:     make(map[string]map[string]*property),
+		mismatches: make(map[Mismatch]int),
+	}
+
+	for name, def := range defs {
+		props := make(map[string]*property)
+
+		for field, s := range def.Properties {
+			p := &property{kind: normalizeType(s.Type), ref: trimRef(s.Ref)}
+
+			if p.kind == "" && p.ref == "" {
+				// 1.2 refers to models by type
+				p.ref = s.Type
+			}
+
+			if p.ref != "" {
+				p.kind = "object"
+			}
+
+			if p.kind == "array" && s.Items != nil {
+				p.ref = trimRef(s.Items.Ref)
+			}
+
+			props[field] = p
+		}
+
+		v.models[name] = props
+	}
+
+	return v, nil
+}
+
+func normalizeType(typ string) string {
+	switch strings.ToLower(typ) {
+	case "int", "integer", "long":
+		return "integer"
+	case "double", "float", "number":
+		return "number"
+	case "string", "date":
+		return "string"
+	case "array":
+		return "array"
+	}
+
+	return ""
+}
+
+func trimRef(ref string) string {
+	return strings.TrimPrefix(ref, "#/definitions/")
+}
+
+// wrappers maps resource types to models of their responses.
+var wrappers = map[string]string{
+	"characters": "CharacterDataWrapper",
+	"comics":     "ComicDataWrapper",
+	"creators":   "CreatorDataWrapper",
+	"events":     "EventDataWrapper",
+	"series":     "SeriesDataWrapper",
+	"stories":    "StoryDataWrapper",
+}
+
+// Validate checks body, a response listing resources of typ, logging and counting every mismatch found.
+func (v *Validator) Validate(typ string, body []byte) error {
+	model, ok := wrappers[typ]
+	if !ok {
+		return fmt.Errorf("unsupported type: %s", typ)
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return err
+	}
+
+	var found []Mismatch
+	v.check(data, model, "", 0, "", &found)
+
+	if len(found) == 0 {
+		return nil
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for _, m := range found {
+		v.mismatches[m]++
+		log.Warn().Str("entity", m.Entity).Int("id", m.ID).Str("field", m.Field).Str("want", m.Want).Str("got", m.Got).Msg("response not matching spec")
+	}
+
+	return nil
+}
+
+// Mismatches returns every mismatch found so far with the number of times seen.
+func (v *Validator) Mismatches() map[Mismatch]int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	out := make(map[Mismatch]int, len(v.mismatches))
+	for m, n := range v.mismatches {
+		out[m] = n
+	}
+
+	return out
+}
+
+// check validates value against model, appending mismatches to found.
+// Models with an id property start a new entity so that fields are reported relative to it.
+func (v *Validator) check(value interface{}, model, entity string, id int, path string, found *[]Mismatch) {
+	props, ok := v.models[model]
+	if !ok {
+		return
+	}
+
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	if _, ok := props["id"]; ok {
+		entity, path = model, ""
+		if n, ok := obj["id"].(float64); ok {
+			id = int(n)
+		}
+	}
+
+	for field, p := range props {
+		fv, ok := obj[field]
+		if !ok || fv == nil {
+			continue
+		}
+
+		fieldPath := field
+		if path != "" {
+			fieldPath = path + "." + field
+		}
+
+		if got := jsonType(fv); !matches(p.kind, got, fv) {
+			*found = append(*found, Mismatch{Entity: entity, ID: id, Field: fieldPath, Want: p.kind, Got: got})
+			continue
+		}
+
+		switch p.kind {
+		case "object":
+			v.check(fv, p.ref, entity, id, fieldPath, found)
+		case "array":
+			for _, item := range fv.([]interface{}) {
+				v.check(item, p.ref, entity, id, fieldPath, found)
+			}
+		}
+	}
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case []interface{}:
+		return "array"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case map[string]interface{}:
+		return "object"
+	case string:
+		return "string"
+	}
+
+	return "null"
+}
+
+func matches(want, got string, value interface{}) bool {
+	if want == "integer" {
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	}
+
+	return want == "" || want == got
+}
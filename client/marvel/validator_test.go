@@ -0,0 +1,83 @@
+package marvel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidator(t *testing.T) {
+	body := []byte(`{
+		"code": 200,
+		"data": {
+			"total": 1,
+			"results": [{
+				"id": 1,
+				"title": "foo",
+				"upc": 123,
+				"description": null,
+				"issueNumber": 1.5,
+				"pageCount": 1.5,
+				"series": {"name": 2},
+				"creators": {"items": [{"name": "bar", "role": 3}]}
+			}]
+		}
+	}`)
+
+	for _, spec := range []string{"swagger/spec-1.0.json", "swagger/spec-2.0.json"} {
+		t.Run(spec, func(t *testing.T) {
+			v, err := NewValidator(spec)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := v.Validate("comics", body); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			want := map[Mismatch]int{
+				{Entity: "Comic", ID: 1, Field: "upc", Want: "string", Got: "number"}:                 1,
+				{Entity: "Comic", ID: 1, Field: "pageCount", Want: "integer", Got: "number"}:          1,
+				{Entity: "Comic", ID: 1, Field: "series.name", Want: "string", Got: "number"}:         1,
+				{Entity: "Comic", ID: 1, Field: "creators.items.role", Want: "string", Got: "number"}: 1,
+			}
+
+			got := v.Mismatches()
+
+			if len(got) != len(want) {
+				t.Errorf("got %d mismatches %v, want %d", len(got), got, len(want))
+			}
+
+			for m, n := range want {
+				if got[m] != n {
+					t.Errorf("got mismatch %q %d times, want %d", m, got[m], n)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_GetWithValidator(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"results":[{"id":1,"name":2}]}}`))
+	}))
+
+	v, err := NewValidator("swagger/spec-1.0.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c := NewClient(ts.URL, "", "", WithValidator(v))
+
+	// mismatches are counted before decoding, which may fail on them
+	for i := 0; i < 2; i++ {
+		c.GetComicCharacters(context.Background(), 1, &Params{})
+	}
+
+	m := Mismatch{Entity: "Character", ID: 1, Field: "name", Want: "string", Got: "number"}
+
+	if got, want := v.Mismatches()[m], 2; got != want {
+		t.Errorf("got mismatch %q %d times, want %d", m, got, want)
+	}
+}
@@ -3,20 +3,20 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net"
 	"os"
 
-	"github.com/avast/retry-go"
 	flag "github.com/spf13/pflag"
 
 	"github.com/loivis/marvel-comics-api-data-loader/client/marvel"
 	"github.com/loivis/marvel-comics-api-data-loader/maco"
 )
 
-var mTypeFunc = map[string]func(int, int) ([]int, error){
+var mTypeFunc = map[string]marvel.PageFunc{
 	maco.TypeCharacters: fetchCharacterIDs,
 	maco.TypeComics:     fetchComicIDs,
 	maco.TypeCreators:   fetchCreatorIDs,
@@ -112,32 +112,21 @@ func current(r io.Reader) (map[string]*collection, error) {
 	return m, err
 }
 
-func fetchIDs(ctx context.Context, typ string, f func(int, int) ([]int, error)) error {
-	count, err := mc.GetCount(ctx, typ)
-	if err != nil {
-		return err
+func fetchIDs(ctx context.Context, typ string, f marvel.PageFunc) error {
+	pager := &marvel.Pager{
+		Attempts: 10,
+		Limit:    limit,
+		Offset:   mStrCol[typ].Offset,
+		RetryIf:  func(offset int, err error) bool { return retryIf(offset)(err) },
 	}
 
-	log.Printf("total %d %s", count, typ)
-
-	offset := mStrCol[typ].Offset
-
-	for i := offset / limit; i < count/limit+1; i++ {
-		log.Printf("fetching %s offset %d", typ, offset)
-		retry.Do(
-			func() error {
-				ids, err := f(offset, limit)
-				if err != nil {
-					return err
-				}
+	return pager.Walk(ctx, f, func(page marvel.ListPage) error {
+		envelope := page.Envelope()
 
-				mStrCol[typ].Offset = offset + limit
-				mStrCol[typ].IDs = append(mStrCol[typ].IDs, ids...)
+		log.Printf("fetched %s offset %d of total %d", typ, envelope.Offset, envelope.Total)
 
-				return nil
-			},
-			retry.RetryIf(retryIf(offset)),
-		)
+		mStrCol[typ].Offset = envelope.Offset + envelope.Count
+		mStrCol[typ].IDs = append(mStrCol[typ].IDs, page.IDs()...)
 
 		b, _ := json.Marshal(mStrCol)
 
@@ -146,103 +135,47 @@ func fetchIDs(ctx context.Context, typ string, f func(int, int) ([]int, error))
 			log.Fatalf("error save mappings: %v", err)
 		}
 
-		log.Printf("saved %s offset %d", typ, offset)
-
-		offset += limit
-	}
+		log.Printf("saved %s offset %d", typ, envelope.Offset)
 
-	return nil
+		return nil
+	})
 }
 
-func fetchCharacterIDs(offset, limit int) ([]int, error) {
-	res, err := mc.GetCharacters(ctx, &marvel.Params{Offset: offset, Limit: 100})
-	if err != nil {
-		return nil, err
-	}
-
-	ids := make([]int, len(res))
-	for i, elem := range res {
-		ids[i] = elem.ID
-	}
-
-	return ids, nil
+func fetchCharacterIDs(ctx context.Context, offset, limit int) (marvel.ListPage, error) {
+	return mc.GetCharacters(ctx, &marvel.Params{Offset: offset, Limit: limit})
 }
 
-func fetchComicIDs(offset, limit int) ([]int, error) {
-	res, err := mc.GetComics(ctx, &marvel.Params{Offset: offset, Limit: 100})
-	if err != nil {
-		return nil, err
-	}
-
-	ids := make([]int, len(res))
-	for i, elem := range res {
-		ids[i] = elem.ID
-	}
-
-	return ids, nil
+func fetchComicIDs(ctx context.Context, offset, limit int) (marvel.ListPage, error) {
+	return mc.GetComics(ctx, &marvel.Params{Offset: offset, Limit: limit})
 }
 
-func fetchCreatorIDs(offset, limit int) ([]int, error) {
-	res, err := mc.GetCreators(ctx, &marvel.Params{Offset: offset, Limit: 100})
-	if err != nil {
-		return nil, err
-	}
-
-	ids := make([]int, len(res))
-	for i, elem := range res {
-		ids[i] = elem.ID
-	}
-
-	return ids, nil
+func fetchCreatorIDs(ctx context.Context, offset, limit int) (marvel.ListPage, error) {
+	return mc.GetCreators(ctx, &marvel.Params{Offset: offset, Limit: limit})
 }
 
-func fetchEventIDs(offset, limit int) ([]int, error) {
-	res, err := mc.GetEvents(ctx, &marvel.Params{Offset: offset, Limit: 100})
-	if err != nil {
-		return nil, err
-	}
-
-	ids := make([]int, len(res))
-	for i, elem := range res {
-		ids[i] = elem.ID
-	}
-
-	return ids, nil
+func fetchEventIDs(ctx context.Context, offset, limit int) (marvel.ListPage, error) {
+	return mc.GetEvents(ctx, &marvel.Params{Offset: offset, Limit: limit})
 }
 
-func fetchSeriesIDs(offset, limit int) ([]int, error) {
-	res, err := mc.GetSeries(ctx, &marvel.Params{Offset: offset, Limit: 100})
-	if err != nil {
-		return nil, err
-	}
-
-	ids := make([]int, len(res))
-	for i, elem := range res {
-		ids[i] = elem.ID
-	}
-
-	return ids, nil
+func fetchSeriesIDs(ctx context.Context, offset, limit int) (marvel.ListPage, error) {
+	return mc.GetSeries(ctx, &marvel.Params{Offset: offset, Limit: limit})
 }
 
-func fetchStoryIDs(offset, limit int) ([]int, error) {
-	res, err := mc.GetStories(ctx, &marvel.Params{Offset: offset, Limit: 100})
-	if err != nil {
-		return nil, err
-	}
-
-	ids := make([]int, len(res))
-	for i, elem := range res {
-		ids[i] = elem.ID
-	}
-
-	return ids, nil
+func fetchStoryIDs(ctx context.Context, offset, limit int) (marvel.ListPage, error) {
+	return mc.GetStories(ctx, &marvel.Params{Offset: offset, Limit: limit})
 }
 
 func retryIf(offset int) func(error) bool {
 	return func(err error) bool {
-		if v, ok := err.(*marvel.APIError); ok && v.Code != 429 {
-			log.Printf("[offset %d] retryable %T error: status code %d", offset, err, v.Code)
-			return true
+		var r interface{ Retryable() bool }
+		if errors.As(err, &r) {
+			if r.Retryable() {
+				log.Printf("[offset %d] retryable %[2]T error: %[2]v", offset, err)
+				return true
+			}
+
+			log.Printf("[offset %d] non-retryable %[2]T error: %[2]v", offset, err)
+			return false
 		}
 
 		if err, ok := err.(net.Error); ok && err.Timeout() {
@@ -7,15 +7,26 @@ import (
 
 // Store .
 type Store interface {
+	GetCheckpoint(ctx context.Context, collection, orderBy string) (*Checkpoint, error)
 	GetCount(ctx context.Context, collection string) (int, error)
+	GetDocs(ctx context.Context, collection string, fields ...string) ([]Doc, error)
+	GetIDs(ctx context.Context, collection string) ([]int, error)
+	GetSyncMark(ctx context.Context, collection string) (time.Time, error)
+	IncompleteDocs(ctx context.Context, collection string) ([]Doc, error)
 	IncompleteIDs(ctx context.Context, collection string) ([]int, error)
+	ReplaceMany(ctx context.Context, collection string, docs []Doc) error
+	Revive(ctx context.Context, collection string, ids []int) error
 	SaveCharacters(ctx context.Context, chars []*Character) error
 	SaveComics(ctx context.Context, comics []*Comic) error
 	SaveCreators(ctx context.Context, creators []*Creator) error
 	SaveEvents(ctx context.Context, events []*Event) error
 	SaveSeries(ctx context.Context, series []*Series) error
 	SaveStories(ctx context.Context, stories []*Story) error
+	SaveCheckpoint(ctx context.Context, cp *Checkpoint) error
 	SaveOne(ctx context.Context, doc Doc) error
+	SaveSyncMark(ctx context.Context, collection string, mark time.Time) error
+	Tombstone(ctx context.Context, collection string, ids []int, tombstone *Tombstone) error
+	TombstonedIDs(ctx context.Context, collection string) ([]int, error)
 }
 
 // Params abstracts common features of all params
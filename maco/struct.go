@@ -1,18 +1,23 @@
 package maco
 
+import "time"
+
 type Character struct {
 	Intact bool `bson:"intact"` // indicator if any data missing
 
-	Comics      []int  `bson:"comics"`                // list of comic id
-	Description string `bson:"description,omitempty"` // short bio or description
-	Events      []int  `bson:"events"`                // list of event id
-	ID          int    `bson:"id"`
-	Modified    string `bson:"modified,omitempty"`
-	Name        string `bson:"name,omitempty"`
-	Series      []int  `bson:"series"`              // list of series id
-	Stories     []int  `bson:"stories"`             // list of story id
-	Thumbnail   string `bson:"thumbnail,omitempty"` // url of thumbnail image
-	URLs        []*URL `bson:"urls"`                // list of resource urls
+	Available   map[string]int `bson:"available,omitempty"`   // available count of each list of related ids, e.g. comics
+	Comics      []int          `bson:"comics"`                // list of comic id
+	Description string         `bson:"description,omitempty"` // short bio or description
+	Events      []int          `bson:"events"`                // list of event id
+	ID          int            `bson:"id"`
+	Mirrored    []*ImageRef    `bson:"mirrored,omitempty"` // local copies of thumbnail and images
+	Modified    string         `bson:"modified,omitempty"`
+	Name        string         `bson:"name,omitempty"`
+	Series      []int          `bson:"series"`              // list of series id
+	Stories     []int          `bson:"stories"`             // list of story id
+	Thumbnail   string         `bson:"thumbnail,omitempty"` // url of thumbnail image
+	Tombstone   *Tombstone     `bson:"tombstone,omitempty"` // set when removed upstream
+	URLs        []*URL         `bson:"urls"`                // list of resource urls
 }
 
 func (char *Character) Identify() int {
@@ -22,34 +27,37 @@ func (char *Character) Identify() int {
 type Comic struct {
 	Intact bool `bson:"intact"` // indicator if any data missing
 
-	Characters         []int         `bson:"characters"`       // list of character id
-	CollectedIssues    []int         `bson:"collected_issues"` // list of comic id
-	Collections        []int         `bson:"collections"`      // list of comic id
-	Creators           []int         `bson:"creators"`         // list of creator id
-	Dates              []*ComicDate  `bson:"dates"`
-	Description        string        `bson:"description,omitempty"`
-	DiamondCode        string        `bson:"diamond_code"`
-	DigitalID          int           `bson:"digital_id"`
-	EAN                string        `bson:"ean,omitempty"`
-	Events             []int         `bson:"events"` // list of event id
-	Format             string        `bson:"format,omitempty"`
-	ID                 int           `bson:"id"`
-	Images             []string      `bson:"images"`
-	ISBN               string        `bson:"isbn,omitempty"`
-	ISSN               string        `bson:"issn,omitempty"`
-	IssueNumber        float64       `bson:"issue_number"`
-	Modified           string        `bson:"modified,omitempty"`
-	PageCount          int           `bson:"page_count"`
-	Prices             []*ComicPrice `bson:"prices"`
-	SeriesID           int           `bson:"series_id"`
-	Stories            []int         `bson:"stories"` // list of story id
-	TextObjects        []*TextObject `bson:"text_objects"`
-	Thumbnail          string        `bson:"thumbnail,omitempty"` // url of thumbnail image
-	Title              string        `bson:"title,omitempty"`
-	UPC                string        `bson:"upc,omitempty"`
-	URLs               []*URL        `bson:"urls"` // list of resource urls
-	VariantDescription string        `bson:"variant_description,omitempty"`
-	Variants           []int         `bson:"variants"` // list of comic id
+	Available          map[string]int `bson:"available,omitempty"` // available count of each list of related ids, e.g. comics
+	Characters         []int          `bson:"characters"`          // list of character id
+	CollectedIssues    []int          `bson:"collected_issues"`    // list of comic id
+	Collections        []int          `bson:"collections"`         // list of comic id
+	Creators           []int          `bson:"creators"`            // list of creator id
+	Dates              []*ComicDate   `bson:"dates"`
+	Description        string         `bson:"description,omitempty"`
+	DiamondCode        string         `bson:"diamond_code"`
+	DigitalID          int            `bson:"digital_id"`
+	EAN                string         `bson:"ean,omitempty"`
+	Events             []int          `bson:"events"` // list of event id
+	Format             string         `bson:"format,omitempty"`
+	ID                 int            `bson:"id"`
+	Images             []string       `bson:"images"`
+	ISBN               string         `bson:"isbn,omitempty"`
+	ISSN               string         `bson:"issn,omitempty"`
+	IssueNumber        float64        `bson:"issue_number"`
+	Mirrored           []*ImageRef    `bson:"mirrored,omitempty"` // local copies of thumbnail and images
+	Modified           string         `bson:"modified,omitempty"`
+	PageCount          int            `bson:"page_count"`
+	Prices             []*ComicPrice  `bson:"prices"`
+	SeriesID           int            `bson:"series_id"`
+	Stories            []int          `bson:"stories"` // list of story id
+	TextObjects        []*TextObject  `bson:"text_objects"`
+	Thumbnail          string         `bson:"thumbnail,omitempty"` // url of thumbnail image
+	Title              string         `bson:"title,omitempty"`
+	UPC                string         `bson:"upc,omitempty"`
+	Tombstone          *Tombstone     `bson:"tombstone,omitempty"` // set when removed upstream
+	URLs               []*URL         `bson:"urls"`                // list of resource urls
+	VariantDescription string         `bson:"variant_description,omitempty"`
+	Variants           []int          `bson:"variants"` // list of comic id
 }
 
 func (comic *Comic) Identify() int {
@@ -59,19 +67,22 @@ func (comic *Comic) Identify() int {
 type Creator struct {
 	Intact bool `bson:"intact"` // indicator if any data missing
 
-	Comics     []int  `bson:"comics"` // list of comic id
-	Events     []int  `bson:"events"` // list of event id
-	FirstName  string `bson:"first_name,omitempty"`
-	FullName   string `bson:"full_name,omitempty"`
-	ID         int    `bson:"id"`
-	LastName   string `bson:"last_name,omitempty"`
-	MiddleName string `bson:"middle_name,omitempty"`
-	Modified   string `bson:"modified,omitempty"`
-	Series     []int  `bson:"series"`  // list of series id
-	Stories    []int  `bson:"stories"` // list of story id
-	Suffix     string `bson:"suffix,omitempty"`
-	Thumbnail  string `bson:"thumbnail,omitempty"` // url of thumbnail image
-	URLs       []*URL `bson:"urls"`                // list of resource urls
+	Available  map[string]int `bson:"available,omitempty"` // available count of each list of related ids, e.g. comics
+	Comics     []int          `bson:"comics"`              // list of comic id
+	Events     []int          `bson:"events"`              // list of event id
+	FirstName  string         `bson:"first_name,omitempty"`
+	FullName   string         `bson:"full_name,omitempty"`
+	ID         int            `bson:"id"`
+	LastName   string         `bson:"last_name,omitempty"`
+	MiddleName string         `bson:"middle_name,omitempty"`
+	Mirrored   []*ImageRef    `bson:"mirrored,omitempty"` // local copies of thumbnail and images
+	Modified   string         `bson:"modified,omitempty"`
+	Series     []int          `bson:"series"`  // list of series id
+	Stories    []int          `bson:"stories"` // list of story id
+	Suffix     string         `bson:"suffix,omitempty"`
+	Thumbnail  string         `bson:"thumbnail,omitempty"` // url of thumbnail image
+	Tombstone  *Tombstone     `bson:"tombstone,omitempty"` // set when removed upstream
+	URLs       []*URL         `bson:"urls"`                // list of resource urls
 }
 
 func (creator *Creator) Identify() int {
@@ -81,21 +92,24 @@ func (creator *Creator) Identify() int {
 type Event struct {
 	Intact bool `bson:"intact"` // indicator if any data missing
 
-	Characters  []int  `bson:"characters"` // list of character id
-	Comics      []int  `bson:"comics"`     // list of comic id
-	Creators    []int  `bson:"creators"`   // list of creator id
-	Description string `bson:"description,omitempty"`
-	End         string `bson:"end,omitempty"` // The date of publication of the last issue in this event.
-	ID          int    `bson:"id"`
-	Modified    string `bson:"modified,omitempty"`
-	Next        int    `bson:"next"`                // id of the event which follows this event
-	Previous    int    `bson:"previous"`            // id of the event which preceded this event
-	Series      []int  `bson:"series"`              // list of series id
-	Start       string `bson:"start,omitempty"`     // The date of publication of the first issue in this event.
-	Stories     []int  `bson:"stories"`             // list of story id
-	Thumbnail   string `bson:"thumbnail,omitempty"` // url of thumbnail image
-	Title       string `bson:"title,omitempty"`
-	URLs        []*URL `bson:"urls"` // list of resource urls
+	Available   map[string]int `bson:"available,omitempty"` // available count of each list of related ids, e.g. comics
+	Characters  []int          `bson:"characters"`          // list of character id
+	Comics      []int          `bson:"comics"`              // list of comic id
+	Creators    []int          `bson:"creators"`            // list of creator id
+	Description string         `bson:"description,omitempty"`
+	End         string         `bson:"end,omitempty"` // The date of publication of the last issue in this event.
+	ID          int            `bson:"id"`
+	Mirrored    []*ImageRef    `bson:"mirrored,omitempty"` // local copies of thumbnail and images
+	Modified    string         `bson:"modified,omitempty"`
+	Next        int            `bson:"next"`                // id of the event which follows this event
+	Previous    int            `bson:"previous"`            // id of the event which preceded this event
+	Series      []int          `bson:"series"`              // list of series id
+	Start       string         `bson:"start,omitempty"`     // The date of publication of the first issue in this event.
+	Stories     []int          `bson:"stories"`             // list of story id
+	Thumbnail   string         `bson:"thumbnail,omitempty"` // url of thumbnail image
+	Title       string         `bson:"title,omitempty"`
+	Tombstone   *Tombstone     `bson:"tombstone,omitempty"` // set when removed upstream
+	URLs        []*URL         `bson:"urls"`                // list of resource urls
 }
 
 func (event *Event) Identify() int {
@@ -105,22 +119,25 @@ func (event *Event) Identify() int {
 type Series struct {
 	Intact bool `bson:"intact"` // indicator if any data missing
 
-	Characters  []int  `bson:"characters"` // list of character id
-	Comics      []int  `bson:"comics"`     // list of comic id
-	Creators    []int  `bson:"creators"`   // list of creator id
-	Description string `bson:"description,omitempty"`
-	EndYear     int    `bson:"end_year"` // The date of publication of the series.
-	Events      []int  `bson:"events"`   // list of event id
-	ID          int    `bson:"id"`
-	Modified    string `bson:"modified,omitempty"`
-	Next        int    `bson:"next"`     // id of the series which follows this series
-	Previous    int    `bson:"previous"` // id of the series which preceded this series
-	Rating      string `bson:"rating,omitempty"`
-	StartYear   int    `bson:"start_year"`          // The date of publication of the series.
-	Stories     []int  `bson:"stories"`             // list of story id
-	Thumbnail   string `bson:"thumbnail,omitempty"` // url of thumbnail image
-	Title       string `bson:"title,omitempty"`
-	URLs        []*URL `bson:"urls"` // list of resource urls
+	Available   map[string]int `bson:"available,omitempty"` // available count of each list of related ids, e.g. comics
+	Characters  []int          `bson:"characters"`          // list of character id
+	Comics      []int          `bson:"comics"`              // list of comic id
+	Creators    []int          `bson:"creators"`            // list of creator id
+	Description string         `bson:"description,omitempty"`
+	EndYear     int            `bson:"end_year"` // The date of publication of the series.
+	Events      []int          `bson:"events"`   // list of event id
+	ID          int            `bson:"id"`
+	Mirrored    []*ImageRef    `bson:"mirrored,omitempty"` // local copies of thumbnail and images
+	Modified    string         `bson:"modified,omitempty"`
+	Next        int            `bson:"next"`     // id of the series which follows this series
+	Previous    int            `bson:"previous"` // id of the series which preceded this series
+	Rating      string         `bson:"rating,omitempty"`
+	StartYear   int            `bson:"start_year"`          // The date of publication of the series.
+	Stories     []int          `bson:"stories"`             // list of story id
+	Thumbnail   string         `bson:"thumbnail,omitempty"` // url of thumbnail image
+	Title       string         `bson:"title,omitempty"`
+	Tombstone   *Tombstone     `bson:"tombstone,omitempty"` // set when removed upstream
+	URLs        []*URL         `bson:"urls"`                // list of resource urls
 }
 
 func (series *Series) Identify() int {
@@ -130,18 +147,21 @@ func (series *Series) Identify() int {
 type Story struct {
 	Intact bool `bson:"intact"` // indicator if any data missing
 
-	Characters    []int  `bson:"characters"` // list of character id
-	Comics        []int  `bson:"comics"`     // list of comic id
-	Creators      []int  `bson:"creators"`   // list of creator id
-	Description   string `bson:"description,omitempty"`
-	Events        []int  `bson:"events"` // list of event id
-	ID            int    `bson:"id"`
-	Modified      string `bson:"modified,omitempty"`
-	OriginalIssue int    `json:"original_issue"`      // comic id
-	Series        []int  `bson:"series"`              // list of series id
-	Thumbnail     string `bson:"thumbnail,omitempty"` // url of thumbnail image
-	Title         string `bson:"title,omitempty"`
-	Type          string `bson:"type,omitempty"`
+	Available     map[string]int `bson:"available,omitempty"` // available count of each list of related ids, e.g. comics
+	Characters    []int          `bson:"characters"`          // list of character id
+	Comics        []int          `bson:"comics"`              // list of comic id
+	Creators      []int          `bson:"creators"`            // list of creator id
+	Description   string         `bson:"description,omitempty"`
+	Events        []int          `bson:"events"` // list of event id
+	ID            int            `bson:"id"`
+	Mirrored      []*ImageRef    `bson:"mirrored,omitempty"` // local copies of thumbnail and images
+	Modified      string         `bson:"modified,omitempty"`
+	OriginalIssue int            `json:"original_issue"`      // comic id
+	Series        []int          `bson:"series"`              // list of series id
+	Thumbnail     string         `bson:"thumbnail,omitempty"` // url of thumbnail image
+	Title         string         `bson:"title,omitempty"`
+	Tombstone     *Tombstone     `bson:"tombstone,omitempty"` // set when removed upstream
+	Type          string         `bson:"type,omitempty"`
 }
 
 func (story *Story) Identify() int {
@@ -164,6 +184,31 @@ type TextObject struct {
 	Type     string `bson:"type"`
 }
 
+// Checkpoint records pages of a collection saved while paging in one order,
+// so that a stopped load resumes at the first page not saved.
+type Checkpoint struct {
+	Collection string    `bson:"collection"`
+	OrderBy    string    `bson:"order_by"`
+	Limit      int       `bson:"limit"`   // page size of offsets
+	Offsets    []int     `bson:"offsets"` // offsets of saved pages in ascending order
+	Total      int       `bson:"total"`   // total from api when last saved
+	Updated    time.Time `bson:"updated"`
+}
+
+// Tombstone marks a document of an entity removed upstream.
+// Documents are kept, but left out of counts and complement.
+type Tombstone struct {
+	Reason  string    `bson:"reason"`  // e.g. unlisted or not found
+	Removed time.Time `bson:"removed"` // when found removed
+}
+
+// ImageRef is a size variant of an image mirrored into blob storage.
+type ImageRef struct {
+	Key     string `bson:"key"`     // content-addressed key in blob storage
+	Source  string `bson:"source"`  // url of the image, as thumbnail or in images
+	Variant string `bson:"variant"` // e.g. portrait_xlarge, empty for full size
+}
+
 type URL struct {
 	Type string `bson:"type"`
 	URL  string `bson:"url"`
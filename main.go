@@ -4,13 +4,19 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/rs/zerolog/log"
+	flag "github.com/spf13/pflag"
 
 	"github.com/loivis/marvel-comics-api-data-loader/client/marvel"
+	"github.com/loivis/marvel-comics-api-data-loader/maco"
+	"github.com/loivis/marvel-comics-api-data-loader/mirror"
 	"github.com/loivis/marvel-comics-api-data-loader/mongodb"
 	"github.com/loivis/marvel-comics-api-data-loader/process"
 )
@@ -22,33 +28,285 @@ func main() {
 
 	ctx := context.Background()
 
-	marvelClient := marvel.NewClient("https://gateway.marvel.com/v1/public/", conf.privateKey, conf.publicKey)
+	var opts []marvel.Option
+
+	if conf.cacheDir != "" {
+		cache, err := marvel.NewFileCache(conf.cacheDir)
+		if err != nil {
+			log.Fatal().Msgf("failed to setup cache: %v", err)
+		}
+
+		opts = append(opts, marvel.WithCache(cache))
+	}
+
+	if conf.cassetteDir != "" {
+		mode := marvel.CassetteReplay
+		if conf.cassetteMode == "record" {
+			mode = marvel.CassetteRecord
+		}
+
+		cassette, err := marvel.NewCassette(conf.cassetteDir, mode)
+		if err != nil {
+			log.Fatal().Msgf("failed to setup cassette: %v", err)
+		}
+
+		opts = append(opts, marvel.WithCassette(cassette))
+	}
+
+	// one scheduler caps requests in flight however deep callers fan out
+	// with an adaptive scheduler, callers fan out enough to fill its highest limit
+	scheduler, fanOut := marvel.NewScheduler(conf.concurrency), conf.concurrency
+	if conf.maxConcurrency > conf.concurrency {
+		scheduler, fanOut = marvel.NewAdaptiveScheduler(conf.concurrency, conf.maxConcurrency, 2*time.Second), conf.maxConcurrency
+	}
+	opts = append(opts, marvel.WithScheduler(scheduler))
+
+	if conf.metricsAddr != "" {
+		// expvar serves metrics at /debug/vars, e.g. marvel_api_concurrency
+		go func() {
+			log.Error().Msgf("metrics server stopped: %v", http.ListenAndServe(conf.metricsAddr, nil))
+		}()
+	}
+
+	if len(conf.keys) > 0 {
+		opts = append(opts, marvel.WithKeys(conf.keys...))
+	}
+
+	var quota *marvel.Quota
+	if conf.dailyLimit > 0 {
+		quota = &marvel.Quota{Budget: conf.dailyLimit, Wait: conf.quotaWait}
+		if conf.quotaFile != "" {
+			quota.Store = marvel.NewFileQuotaStore(conf.quotaFile)
+		}
+		defer flushQuota(quota)
+
+		opts = append(opts, marvel.WithQuota(quota))
+	}
+
+	var validator *marvel.Validator
+	if conf.specFile != "" {
+		var err error
+		validator, err = marvel.NewValidator(conf.specFile)
+		if err != nil {
+			log.Fatal().Msgf("failed to setup validator: %v", err)
+		}
+
+		opts = append(opts, marvel.WithValidator(validator))
+	}
+
+	marvelClient := marvel.NewClient("https://gateway.marvel.com/v1/public/", conf.privateKey, conf.publicKey, opts...)
 
 	mongodb, err := mongodb.New(conf.mongodbURI, conf.mongodbDatabase)
 	if err != nil {
 		log.Fatal().Msgf("failed to setup mongodb: %v", err)
 	}
 
-	p := process.NewProcessor(marvelClient, mongodb, conf.privateKey, conf.publicKey)
+	var store maco.Store = mongodb
+
+	if conf.mirrorDir != "" || conf.mirrorBucket != "" {
+		var blobs mirror.Blobs
+		if conf.mirrorDir != "" {
+			blobs, err = mirror.NewFileBlobs(conf.mirrorDir)
+		} else {
+			blobs, err = mongodb.GridFS(conf.mirrorBucket)
+		}
+		if err != nil {
+			log.Fatal().Msgf("failed to setup image mirror: %v", err)
+		}
+
+		store = mirror.NewStore(store, mirror.New(blobs, conf.mirrorVariants...))
+	}
+
+	p := process.NewProcessor(marvelClient, store, conf.privateKey, conf.publicKey,
+		process.WithConcurrency(fanOut),
+		process.WithTypes(conf.types...),
+		process.WithPhases(conf.phases...),
+	)
+
+	if conf.dryRun {
+		est, err := p.DryRun(ctx)
+		if err != nil {
+			log.Fatal().Msgf("failed to estimate run: %v", err)
+		}
+
+		fmt.Print(est)
+		return
+	}
+
+	if conf.checkRefs || conf.repairRefs {
+		in, err := p.CheckReferences(ctx)
+		if err != nil {
+			log.Fatal().Msgf("failed to check references: %v", err)
+		}
+
+		fmt.Print(in)
+
+		if !conf.repairRefs || len(in.Dangling) == 0 {
+			return
+		}
+
+		if err := p.RepairReferences(ctx, in); err != nil {
+			log.Fatal().Msgf("failed to repair references: %v", err)
+		}
+
+		in, err = p.CheckReferences(ctx)
+		if err != nil {
+			log.Fatal().Msgf("failed to check references: %v", err)
+		}
+
+		fmt.Print("after repair, ", in)
+		return
+	}
+
+	run := p.Process
+	if conf.loadMode == "incremental" {
+		run = p.Sync
+	}
+
+	err = run(ctx)
 
-	if err := p.Process(ctx); err != nil {
+	if quota != nil {
+		// saved before exiting, as log.Fatal below skips deferred calls
+		flushQuota(quota)
+	}
+
+	for coercion, n := range marvel.Coercions() {
+		log.Info().Int("count", n).Msgf("decoded %s", coercion)
+	}
+
+	if validator != nil {
+		logMismatches(validator)
+	}
+
+	if conf.reportDir != "" {
+		summary, err := p.Report().Write(conf.reportDir)
+		if err != nil {
+			log.Error().Msgf("failed to write anomaly report: %v", err)
+		} else {
+			fmt.Fprint(os.Stderr, summary)
+		}
+	}
+
+	if err == process.ErrQuotaExhausted {
+		log.Info().Msg("api quota exhausted, run again after utc midnight to resume")
+		return
+	}
+	if err != nil {
 		log.Fatal().Msg(err.Error())
 	}
 }
 
+// flushQuota saves quota usage not saved yet.
+func flushQuota(q *marvel.Quota) {
+	if err := q.Flush(); err != nil {
+		log.Error().Msgf("failed to save quota usage: %v", err)
+	}
+}
+
+// logMismatches logs how often each field of each entity type did not match the spec.
+func logMismatches(v *marvel.Validator) {
+	counts := make(map[string]int)
+	for m, n := range v.Mismatches() {
+		counts[m.Entity+"."+m.Field+": got "+m.Got+", want "+m.Want] += n
+	}
+
+	for field, n := range counts {
+		log.Warn().Int("count", n).Msgf("spec mismatch %s", field)
+	}
+}
+
 type config struct {
+	cacheDir        string
+	cassetteDir     string
+	cassetteMode    string
+	checkRefs       bool
+	concurrency     int
+	dailyLimit      int
+	dryRun          bool
+	keys            []marvel.Key
+	loadMode        string
+	maxConcurrency  int
+	metricsAddr     string
+	mirrorBucket    string
+	mirrorDir       string
+	mirrorVariants  []string
 	mongodbURI      string
 	mongodbDatabase string
+	phases          []string
 	privateKey      string
 	publicKey       string
+	quotaFile       string
+	quotaWait       bool
+	repairRefs      bool
+	reportDir       string
+	specFile        string
+	types           []string
 }
 
 func readConfig() *config {
+	var (
+		checkRefs, dryRun, repairRefs bool
+		phases, types                 []string
+	)
+
+	flag.StringSliceVar(&types, "types", nil, "types to load in order of dependencies, e.g. characters,events, all if empty")
+	flag.StringSliceVar(&phases, "phases", nil, "phases to run of each type out of basic,enumerate,infer,complement,verify, basic,infer,complement if empty")
+	flag.BoolVar(&dryRun, "dry-run", false, "estimate api calls of each phase without loading anything")
+	flag.BoolVar(&checkRefs, "check-refs", false, "report references of stored documents to entities missing in store without loading anything")
+	flag.BoolVar(&repairRefs, "repair-refs", false, "like --check-refs, then fetch and insert missing entities")
+	flag.Parse()
+
+	concurrency, _ := strconv.Atoi(os.Getenv("MARVEL_API_CONCURRENCY"))
+	if concurrency <= 0 {
+		concurrency = 10
+	}
+
+	maxConcurrency, _ := strconv.Atoi(os.Getenv("MARVEL_API_MAX_CONCURRENCY"))
+
+	dailyLimit, _ := strconv.Atoi(os.Getenv("MARVEL_API_DAILY_LIMIT"))
+
+	mirrorVariants := []string{"portrait_xlarge", "standard_fantastic"}
+	if v := os.Getenv("MIRROR_VARIANTS"); v != "" {
+		mirrorVariants = strings.Split(v, ",")
+	}
+
+	// extra keys formatted as public:private,public:private
+	var keys []marvel.Key
+	for _, pair := range strings.Split(os.Getenv("MARVEL_API_KEYS"), ",") {
+		ss := strings.SplitN(strings.TrimSpace(pair), ":", 2)
+		if len(ss) != 2 {
+			continue
+		}
+
+		keys = append(keys, marvel.Key{Public: ss[0], Private: ss[1]})
+	}
+
 	return &config{
+		cacheDir:        os.Getenv("MARVEL_CACHE_DIR"),
+		cassetteDir:     os.Getenv("MARVEL_CASSETTE_DIR"),
+		cassetteMode:    os.Getenv("MARVEL_CASSETTE_MODE"),
+		checkRefs:       checkRefs,
+		concurrency:     concurrency,
+		dailyLimit:      dailyLimit,
+		dryRun:          dryRun,
+		keys:            keys,
+		loadMode:        os.Getenv("LOAD_MODE"),
+		maxConcurrency:  maxConcurrency,
+		metricsAddr:     os.Getenv("METRICS_ADDR"),
+		mirrorBucket:    os.Getenv("MIRROR_GRIDFS_BUCKET"),
+		mirrorDir:       os.Getenv("MIRROR_DIR"),
+		mirrorVariants:  mirrorVariants,
 		mongodbURI:      os.Getenv("MONGODB_URI"),
 		mongodbDatabase: os.Getenv("MONGODB_DATABASE"),
+		phases:          phases,
 		privateKey:      os.Getenv("MARVEL_API_PRIVATE_KEY"),
 		publicKey:       os.Getenv("MARVEL_API_PUBLIC_KEY"),
+		quotaFile:       os.Getenv("MARVEL_API_QUOTA_FILE"),
+		quotaWait:       os.Getenv("MARVEL_API_QUOTA_WAIT") == "true",
+		repairRefs:      repairRefs,
+		reportDir:       os.Getenv("REPORT_DIR"),
+		specFile:        os.Getenv("MARVEL_SPEC_FILE"),
+		types:           types,
 	}
 }
 
@@ -73,16 +331,42 @@ func (c *config) String() string {
 		return ""
 	}
 
+	var keys []string
+	for _, key := range c.keys {
+		keys = append(keys, key.Public+":"+hideIfSet(key.Private))
+	}
+
 	var buf bytes.Buffer
 	w := tabwriter.NewWriter(&buf, 0, 1, 4, ' ', 0)
 	for _, e := range []struct {
 		k string
 		v interface{}
 	}{
+		{"LOAD_MODE", c.loadMode},
+		{"MARVEL_CACHE_DIR", c.cacheDir},
+		{"MARVEL_CASSETTE_DIR", c.cassetteDir},
+		{"MARVEL_CASSETTE_MODE", c.cassetteMode},
 		{"MONGODB_URI", hideIfSet(c.mongodbURI)},
 		{"MONGODB_DATABASE", c.mongodbDatabase},
 		{"MARVEL_API_PRIVATE_KEY", hideIfSet(c.privateKey)},
 		{"MARVEL_API_PUBLIC_KEY", c.publicKey},
+		{"MARVEL_API_KEYS", strings.Join(keys, ",")},
+		{"MARVEL_API_CONCURRENCY", c.concurrency},
+		{"MARVEL_API_MAX_CONCURRENCY", c.maxConcurrency},
+		{"MARVEL_API_DAILY_LIMIT", c.dailyLimit},
+		{"MARVEL_API_QUOTA_FILE", c.quotaFile},
+		{"MARVEL_API_QUOTA_WAIT", c.quotaWait},
+		{"MARVEL_SPEC_FILE", c.specFile},
+		{"METRICS_ADDR", c.metricsAddr},
+		{"MIRROR_DIR", c.mirrorDir},
+		{"MIRROR_GRIDFS_BUCKET", c.mirrorBucket},
+		{"MIRROR_VARIANTS", strings.Join(c.mirrorVariants, ",")},
+		{"REPORT_DIR", c.reportDir},
+		{"--types", strings.Join(c.types, ",")},
+		{"--phases", strings.Join(c.phases, ",")},
+		{"--dry-run", c.dryRun},
+		{"--check-refs", c.checkRefs},
+		{"--repair-refs", c.repairRefs},
 	} {
 		fmt.Fprintf(w, "%s\t%v\n", e.k, e.v)
 	}
@@ -0,0 +1,111 @@
+package mirror
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Blobs is a content-addressed storage of mirrored images.
+type Blobs interface {
+	// Has returns whether a blob is stored with key.
+	Has(ctx context.Context, key string) (bool, error)
+	// Put stores content read from r with key.
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Source returns the key of the blob mirrored from url, empty if none recorded.
+	Source(ctx context.Context, url string) (string, error)
+	// SetSource records url as the source of the blob with key.
+	SetSource(ctx context.Context, url, key string) error
+}
+
+// FileBlobs is Blobs kept as files under a directory,
+// sharded by the first two characters of keys, e.g. 9f/9f86d081884c7d65.jpg.
+// Sources are kept under sources/, each file named by the sha256 of a url and holding the key of its blob.
+type FileBlobs struct {
+	dir string
+}
+
+// NewFileBlobs returns FileBlobs kept in dir, creating it if missing.
+func NewFileBlobs(dir string) (*FileBlobs, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &FileBlobs{dir: dir}, nil
+}
+
+// Has returns whether a blob is stored with key.
+func (fb *FileBlobs) Has(ctx context.Context, key string) (bool, error) {
+	_, err := os.Stat(fb.path(key))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Put stores content read from r with key.
+func (fb *FileBlobs) Put(ctx context.Context, key string, r io.Reader) error {
+	return write(fb.path(key), r)
+}
+
+// write writes content read from r into a file at path, renamed in place once complete.
+func write(path string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), ".blob-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// Source returns the key of the blob mirrored from url, empty if none recorded.
+func (fb *FileBlobs) Source(ctx context.Context, url string) (string, error) {
+	b, err := ioutil.ReadFile(fb.sourcePath(url))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// SetSource records url as the source of the blob with key.
+func (fb *FileBlobs) SetSource(ctx context.Context, url, key string) error {
+	return write(fb.sourcePath(url), strings.NewReader(key))
+}
+
+func (fb *FileBlobs) sourcePath(url string) string {
+	return filepath.Join(fb.dir, "sources", fmt.Sprintf("%x", sha256.Sum256([]byte(url))))
+}
+
+func (fb *FileBlobs) path(key string) string {
+	if len(key) < 2 {
+		return filepath.Join(fb.dir, key)
+	}
+
+	return filepath.Join(fb.dir, key[:2], key)
+}
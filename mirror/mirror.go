@@ -0,0 +1,237 @@
+// Package mirror copies images of marvel entities into blob storage,
+// so that they can be served without going to marvel.
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/loivis/marvel-comics-api-data-loader/maco"
+)
+
+// Mirror downloads size variants of images into Blobs.
+// See https://developer.marvel.com/documentation/images for variants.
+type Mirror struct {
+	blobs       Blobs
+	concurrency int
+	hc          *http.Client
+	variants    []string
+
+	mu     sync.Mutex
+	images map[string][]*maco.ImageRef // map of image url to refs of mirrored variants
+}
+
+// New returns a Mirror storing variants of images into blobs, e.g. portrait_xlarge or standard_fantastic.
+// An empty variant stands for the image in full size.
+func New(blobs Blobs, variants ...string) *Mirror {
+	if len(variants) == 0 {
+		variants = []string{""}
+	}
+
+	return &Mirror{
+		blobs:       blobs,
+		concurrency: 4,
+		hc:          &http.Client{Timeout: 60 * time.Second},
+		variants:    variants,
+		images:      make(map[string][]*maco.ImageRef),
+	}
+}
+
+// placeholder is in path of images standing for missing ones.
+const placeholder = "image_not_available"
+
+// Image mirrors variants of the image at url, e.g. http://i.annihil.us/u/prod/marvel/i/mg/3/40/4bb4680432f73.jpg,
+// and returns refs of them. Placeholders for missing images are skipped.
+func (m *Mirror) Image(ctx context.Context, url string) ([]*maco.ImageRef, error) {
+	ext := path.Ext(url)
+	if ext == "" || strings.Contains(url, placeholder) {
+		return nil, nil
+	}
+
+	m.mu.Lock()
+	refs, ok := m.images[url]
+	m.mu.Unlock()
+
+	if ok {
+		return refs, nil
+	}
+
+	base := strings.TrimSuffix(url, ext)
+
+	refs = make([]*maco.ImageRef, len(m.variants))
+
+	g, gctx := errgroup.WithContext(ctx)
+
+	for i, variant := range m.variants {
+		i, variant := i, variant
+
+		src := base + ext
+		if variant != "" {
+			src = base + "/" + variant + ext
+		}
+
+		g.Go(func() error {
+			key, err := m.store(gctx, src, ext)
+			if err != nil {
+				return fmt.Errorf("error mirroring %q: %v", src, err)
+			}
+
+			refs[i] = &maco.ImageRef{Key: key, Source: url, Variant: variant}
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	m.images[url] = refs
+	m.mu.Unlock()
+
+	return refs, nil
+}
+
+// store downloads src into blobs unless mirrored before, returning its key.
+func (m *Mirror) store(ctx context.Context, src, ext string) (string, error) {
+	key, err := m.blobs.Source(ctx, src)
+	if err != nil {
+		return "", err
+	}
+
+	if key != "" {
+		ok, err := m.blobs.Has(ctx, key)
+		if err != nil {
+			return "", err
+		}
+
+		if ok {
+			return key, nil
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, src, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := m.hc.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	key = fmt.Sprintf("%x%s", sha256.Sum256(b), ext)
+
+	ok, err := m.blobs.Has(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	if !ok {
+		if err := m.blobs.Put(ctx, key, bytes.NewReader(b)); err != nil {
+			return "", err
+		}
+	}
+
+	// sources are recorded once blobs are stored, so that later runs skip downloading them
+	return key, m.blobs.SetSource(ctx, src, key)
+}
+
+// Docs mirrors thumbnails, and images of comics, of docs, recording refs in Mirrored of each doc.
+// Docs failing to mirror are logged and left without refs, so only cancellation of ctx is returned.
+func (m *Mirror) Docs(ctx context.Context, docs ...maco.Doc) error {
+	var wg sync.WaitGroup
+
+	conCh := make(chan struct{}, m.concurrency)
+
+loop:
+	for _, doc := range docs {
+		select {
+		case conCh <- struct{}{}:
+		case <-ctx.Done():
+			break loop
+		}
+
+		wg.Add(1)
+
+		go func(doc maco.Doc) {
+			defer func() {
+				<-conCh
+				wg.Done()
+			}()
+
+			if err := m.doc(ctx, doc); err != nil && ctx.Err() == nil {
+				log.Warn().Int("id", doc.Identify()).Msgf("error mirroring images of %T: %v", doc, err)
+			}
+		}(doc)
+	}
+
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+func (m *Mirror) doc(ctx context.Context, doc maco.Doc) error {
+	var urls []string
+	var mirrored *[]*maco.ImageRef
+
+	switch d := doc.(type) {
+	case *maco.Character:
+		urls, mirrored = []string{d.Thumbnail}, &d.Mirrored
+	case *maco.Comic:
+		urls, mirrored = append([]string{d.Thumbnail}, d.Images...), &d.Mirrored
+	case *maco.Creator:
+		urls, mirrored = []string{d.Thumbnail}, &d.Mirrored
+	case *maco.Event:
+		urls, mirrored = []string{d.Thumbnail}, &d.Mirrored
+	case *maco.Series:
+		urls, mirrored = []string{d.Thumbnail}, &d.Mirrored
+	case *maco.Story:
+		urls, mirrored = []string{d.Thumbnail}, &d.Mirrored
+	default:
+		return fmt.Errorf("unsupported doc type: %T", doc)
+	}
+
+	var refs []*maco.ImageRef
+	seen := make(map[string]bool)
+
+	for _, url := range urls {
+		if url == "" || seen[url] {
+			continue
+		}
+		seen[url] = true
+
+		r, err := m.Image(ctx, url)
+		if err != nil {
+			return err
+		}
+
+		refs = append(refs, r...)
+	}
+
+	*mirrored = refs
+
+	return nil
+}
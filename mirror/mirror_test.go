@@ -0,0 +1,161 @@
+package mirror
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/loivis/marvel-comics-api-data-loader/maco"
+)
+
+func TestMirror_Docs(t *testing.T) {
+	var mu sync.Mutex
+	requests := make(map[string]int)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/mg/a/portrait_xlarge.jpg", "/mg/b/portrait_xlarge.jpg":
+			// same content behind different urls is stored once
+			w.Write([]byte("portrait"))
+		case "/mg/a/standard_fantastic.jpg", "/mg/b/standard_fantastic.jpg":
+			w.Write([]byte("standard"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "mirror")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	blobs, err := NewFileBlobs(dir)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	store := &fakeStore{}
+
+	s := NewStore(store, New(blobs, "portrait_xlarge", "standard_fantastic"))
+
+	comics := []*maco.Comic{
+		{ID: 1, Thumbnail: ts.URL + "/mg/a.jpg", Images: []string{ts.URL + "/mg/a.jpg", ts.URL + "/mg/b.jpg"}},
+		{ID: 2, Thumbnail: ts.URL + "/mg/image_not_available.jpg"},
+		{ID: 3, Thumbnail: ts.URL + "/mg/missing.jpg"},
+	}
+
+	if err := s.SaveComics(context.Background(), comics); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if got, want := len(store.comics), 3; got != want {
+		t.Fatalf("got %d saved comics, want %d", got, want)
+	}
+
+	t.Run("Refs", func(t *testing.T) {
+		refs := comics[0].Mirrored
+
+		if got, want := len(refs), 4; got != want {
+			t.Fatalf("got %d refs, want %d", got, want)
+		}
+
+		for i, want := range []maco.ImageRef{
+			{Source: ts.URL + "/mg/a.jpg", Variant: "portrait_xlarge"},
+			{Source: ts.URL + "/mg/a.jpg", Variant: "standard_fantastic"},
+			{Source: ts.URL + "/mg/b.jpg", Variant: "portrait_xlarge"},
+			{Source: ts.URL + "/mg/b.jpg", Variant: "standard_fantastic"},
+		} {
+			if got := refs[i]; got.Source != want.Source || got.Variant != want.Variant {
+				t.Errorf("got ref %d %+v, want %+v", i, got, want)
+			}
+
+			if ok, err := blobs.Has(context.Background(), refs[i].Key); err != nil || !ok {
+				t.Errorf("got no blob %q: %v", refs[i].Key, err)
+			}
+		}
+
+		if got, want := refs[0].Key, refs[2].Key; got != want {
+			t.Errorf("got key %q, want %q", got, want)
+		}
+	})
+
+	t.Run("Placeholder", func(t *testing.T) {
+		if got := comics[1].Mirrored; got != nil {
+			t.Errorf("got refs %v, want none", got)
+		}
+
+		for path := range requests {
+			if filepath.Base(filepath.Dir(path)) == placeholder {
+				t.Errorf("got request %q", path)
+			}
+		}
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		if got := comics[2].Mirrored; got != nil {
+			t.Errorf("got refs %v, want none", got)
+		}
+	})
+
+	t.Run("Blobs", func(t *testing.T) {
+		files, err := filepath.Glob(filepath.Join(dir, "*", "*.jpg"))
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if got, want := len(files), 2; got != want {
+			t.Errorf("got %d blobs %v, want %d", got, files, want)
+		}
+
+		if got, want := requests["/mg/a/portrait_xlarge.jpg"], 1; got != want {
+			t.Errorf("got %d requests, want %d", got, want)
+		}
+	})
+
+	t.Run("Rerun", func(t *testing.T) {
+		// a new mirror, as in a later run, skips images mirrored from the same urls before
+		comic := &maco.Comic{ID: 1, Thumbnail: ts.URL + "/mg/a.jpg", Images: []string{ts.URL + "/mg/b.jpg"}}
+
+		if err := New(blobs, "portrait_xlarge", "standard_fantastic").Docs(context.Background(), comic); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if got, want := len(comic.Mirrored), 4; got != want {
+			t.Fatalf("got %d refs, want %d", got, want)
+		}
+
+		for i, ref := range comic.Mirrored {
+			if got, want := ref.Key, comics[0].Mirrored[i].Key; got != want {
+				t.Errorf("got key %q of ref %d, want %q", got, i, want)
+			}
+		}
+
+		for path, n := range requests {
+			if got, want := n, 1; got != want {
+				t.Errorf("got %d requests of %q, want %d", got, path, want)
+			}
+		}
+	})
+}
+
+type fakeStore struct {
+	maco.Store
+
+	comics []*maco.Comic
+}
+
+func (fs *fakeStore) SaveComics(ctx context.Context, comics []*maco.Comic) error {
+	fs.comics = append(fs.comics, comics...)
+	return nil
+}
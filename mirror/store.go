@@ -0,0 +1,121 @@
+package mirror
+
+import (
+	"context"
+
+	"github.com/loivis/marvel-comics-api-data-loader/maco"
+)
+
+// Store is a maco.Store mirroring images of docs before saving them.
+type Store struct {
+	maco.Store
+
+	mirror *Mirror
+}
+
+// NewStore returns a Store saving docs into store after mirroring their images with m.
+func NewStore(store maco.Store, m *Mirror) *Store {
+	return &Store{Store: store, mirror: m}
+}
+
+// ReplaceMany .
+func (s *Store) ReplaceMany(ctx context.Context, collection string, docs []maco.Doc) error {
+	if err := s.mirror.Docs(ctx, docs...); err != nil {
+		return err
+	}
+
+	return s.Store.ReplaceMany(ctx, collection, docs)
+}
+
+// SaveCharacters .
+func (s *Store) SaveCharacters(ctx context.Context, chars []*maco.Character) error {
+	docs := make([]maco.Doc, len(chars))
+	for i, char := range chars {
+		docs[i] = char
+	}
+
+	if err := s.mirror.Docs(ctx, docs...); err != nil {
+		return err
+	}
+
+	return s.Store.SaveCharacters(ctx, chars)
+}
+
+// SaveComics .
+func (s *Store) SaveComics(ctx context.Context, comics []*maco.Comic) error {
+	docs := make([]maco.Doc, len(comics))
+	for i, comic := range comics {
+		docs[i] = comic
+	}
+
+	if err := s.mirror.Docs(ctx, docs...); err != nil {
+		return err
+	}
+
+	return s.Store.SaveComics(ctx, comics)
+}
+
+// SaveCreators .
+func (s *Store) SaveCreators(ctx context.Context, creators []*maco.Creator) error {
+	docs := make([]maco.Doc, len(creators))
+	for i, creator := range creators {
+		docs[i] = creator
+	}
+
+	if err := s.mirror.Docs(ctx, docs...); err != nil {
+		return err
+	}
+
+	return s.Store.SaveCreators(ctx, creators)
+}
+
+// SaveEvents .
+func (s *Store) SaveEvents(ctx context.Context, events []*maco.Event) error {
+	docs := make([]maco.Doc, len(events))
+	for i, event := range events {
+		docs[i] = event
+	}
+
+	if err := s.mirror.Docs(ctx, docs...); err != nil {
+		return err
+	}
+
+	return s.Store.SaveEvents(ctx, events)
+}
+
+// SaveSeries .
+func (s *Store) SaveSeries(ctx context.Context, series []*maco.Series) error {
+	docs := make([]maco.Doc, len(series))
+	for i, one := range series {
+		docs[i] = one
+	}
+
+	if err := s.mirror.Docs(ctx, docs...); err != nil {
+		return err
+	}
+
+	return s.Store.SaveSeries(ctx, series)
+}
+
+// SaveStories .
+func (s *Store) SaveStories(ctx context.Context, stories []*maco.Story) error {
+	docs := make([]maco.Doc, len(stories))
+	for i, story := range stories {
+		docs[i] = story
+	}
+
+	if err := s.mirror.Docs(ctx, docs...); err != nil {
+		return err
+	}
+
+	return s.Store.SaveStories(ctx, stories)
+}
+
+// SaveOne .
+func (s *Store) SaveOne(ctx context.Context, doc maco.Doc) error {
+	if err := s.mirror.Docs(ctx, doc); err != nil {
+		return err
+	}
+
+	return s.Store.SaveOne(ctx, doc)
+}
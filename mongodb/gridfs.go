@@ -0,0 +1,99 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GridFS is blob storage in a gridfs bucket, keyed by file name.
+// Sources of files are kept in collection <bucket>.sources, e.g. images.sources.
+type GridFS struct {
+	mu      sync.Mutex // deadlines are set on the bucket, so calls take turns
+	bucket  *gridfs.Bucket
+	sources *mongo.Collection
+	timeout time.Duration
+}
+
+// GridFS returns blob storage in the gridfs bucket with name.
+func (m *MongoDB) GridFS(name string) (*GridFS, error) {
+	bucket, err := gridfs.NewBucket(m.client.Database(m.database), options.GridFSBucket().SetName(name))
+	if err != nil {
+		return nil, fmt.Errorf("error opening gridfs bucket %q: %v", name, err)
+	}
+
+	sources := m.client.Database(m.database).Collection(name + ".sources")
+
+	return &GridFS{bucket: bucket, sources: sources, timeout: m.timeout}, nil
+}
+
+// Has returns whether a file is stored with key as name.
+func (g *GridFS) Has(ctx context.Context, key string) (bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.bucket.SetReadDeadline(time.Now().Add(g.timeout)); err != nil {
+		return false, err
+	}
+
+	cursor, err := g.bucket.Find(bson.D{{Key: "filename", Value: key}})
+	if err != nil {
+		return false, err
+	}
+	defer cursor.Close(ctx)
+
+	return cursor.Next(ctx), cursor.Err()
+}
+
+// Put stores content read from r as a file with key as name.
+func (g *GridFS) Put(ctx context.Context, key string, r io.Reader) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.bucket.SetWriteDeadline(time.Now().Add(g.timeout)); err != nil {
+		return err
+	}
+
+	_, err := g.bucket.UploadFromStream(key, r)
+
+	return err
+}
+
+// Source returns the name of the file mirrored from url, empty if none recorded.
+func (g *GridFS) Source(ctx context.Context, url string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+
+	var elem struct{ Key string }
+
+	err := g.sources.FindOne(ctx, bson.D{{Key: "url", Value: url}}).Decode(&elem)
+	if err == mongo.ErrNoDocuments {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return elem.Key, nil
+}
+
+// SetSource records url as the source of the file with key as name.
+func (g *GridFS) SetSource(ctx context.Context, url, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+
+	_, err := g.sources.ReplaceOne(ctx,
+		bson.D{{Key: "url", Value: url}},
+		bson.D{{Key: "url", Value: url}, {Key: "key", Value: key}},
+		options.Replace().SetUpsert(true),
+	)
+
+	return err
+}
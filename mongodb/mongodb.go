@@ -24,8 +24,14 @@ const (
 	ColEvents     = "events"
 	ColSeries     = "series"
 	ColStories    = "stories"
+
+	ColCheckpoints = "checkpoints" // saved pages of paged loads
+	ColSyncMarks   = "sync_marks"  // high-water marks of incremental sync
 )
 
+// alive filters out documents of entities removed upstream.
+var alive = bson.E{Key: "tombstone", Value: bson.D{{Key: "$exists", Value: false}}}
+
 type MongoDB struct {
 	client   *mongo.Client
 	database string
@@ -69,23 +75,126 @@ func (m *MongoDB) GetCount(ctx context.Context, collection string) (int, error)
 
 	col := m.client.Database(m.database).Collection(collection)
 
-	count, err := col.CountDocuments(ctx, bson.D{})
+	// documents of entities removed upstream are not counted
+	count, err := col.CountDocuments(ctx, bson.D{alive})
 
 	return int(count), err
 }
 
+func (m *MongoDB) GetCheckpoint(ctx context.Context, collection, orderBy string) (*maco.Checkpoint, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	col := m.client.Database(m.database).Collection(ColCheckpoints)
+
+	var cp maco.Checkpoint
+
+	err := col.FindOne(ctx, bson.D{{Key: "collection", Value: collection}, {Key: "order_by", Value: orderBy}}).Decode(&cp)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error finding checkpoint: %v", err)
+	}
+
+	return &cp, nil
+}
+
+func (m *MongoDB) SaveCheckpoint(ctx context.Context, cp *maco.Checkpoint) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	col := m.client.Database(m.database).Collection(ColCheckpoints)
+
+	_, err := col.ReplaceOne(ctx,
+		bson.D{{Key: "collection", Value: cp.Collection}, {Key: "order_by", Value: cp.OrderBy}},
+		cp,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Str("collection", cp.Collection).Int("pages", len(cp.Offsets)).Msg("saved checkpoint")
+
+	return nil
+}
+
+// GetIDs returns ids of all documents in collection.
+func (m *MongoDB) GetIDs(ctx context.Context, collection string) ([]int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	ids, err := m.getAllIds(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	// copy as the cache keeps growing with saved docs
+	return append([]int{}, ids...), nil
+}
+
+func (m *MongoDB) GetSyncMark(ctx context.Context, collection string) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	col := m.client.Database(m.database).Collection(ColSyncMarks)
+
+	var elem struct{ Mark time.Time }
+
+	err := col.FindOne(ctx, bson.D{{Key: "collection", Value: collection}}).Decode(&elem)
+	if err == mongo.ErrNoDocuments {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error finding sync mark: %v", err)
+	}
+
+	return elem.Mark, nil
+}
+
+func (m *MongoDB) SaveSyncMark(ctx context.Context, collection string, mark time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	col := m.client.Database(m.database).Collection(ColSyncMarks)
+
+	_, err := col.ReplaceOne(ctx,
+		bson.D{{Key: "collection", Value: collection}},
+		bson.D{{Key: "collection", Value: collection}, {Key: "mark", Value: mark}},
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Str("collection", collection).Time("mark", mark).Msg("saved sync mark")
+
+	return nil
+}
+
 func (m *MongoDB) IncompleteIDs(ctx context.Context, collection string) ([]int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
 	defer cancel()
 
+	return m.findIDs(ctx, collection, bson.D{{Key: "intact", Value: false}, alive})
+}
+
+// TombstonedIDs returns ids of documents of collection marked removed upstream.
+func (m *MongoDB) TombstonedIDs(ctx context.Context, collection string) ([]int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	return m.findIDs(ctx, collection, bson.D{{Key: "tombstone", Value: bson.D{{Key: "$exists", Value: true}}}})
+}
+
+// findIDs returns ids of documents of collection matching filter.
+func (m *MongoDB) findIDs(ctx context.Context, collection string, filter bson.D) ([]int, error) {
 	col := m.client.Database(m.database).Collection(collection)
 
-	cur, err := col.Find(ctx,
-		bson.D{{Key: "intact", Value: false}},
-		options.Find().SetProjection(bson.D{{Key: "id", Value: 1}}),
-	)
+	cur, err := col.Find(ctx, filter, options.Find().SetProjection(bson.D{{Key: "id", Value: 1}}))
 	if err != nil {
-		return nil, fmt.Errorf("error finding incomplete documents: %v", err)
+		return nil, fmt.Errorf("error finding documents: %v", err)
 	}
 
 	var ids []int
@@ -109,6 +218,79 @@ func (m *MongoDB) IncompleteIDs(ctx context.Context, collection string) ([]int,
 	return ids, nil
 }
 
+// IncompleteDocs returns documents of collection not marked intact, leaving out those tombstoned.
+func (m *MongoDB) IncompleteDocs(ctx context.Context, collection string) ([]maco.Doc, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	return m.findDocs(ctx, collection, bson.D{{Key: "intact", Value: false}, alive}, options.Find())
+}
+
+// GetDocs returns documents of collection holding only id and fields, leaving out those tombstoned.
+func (m *MongoDB) GetDocs(ctx context.Context, collection string, fields ...string) ([]maco.Doc, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	projection := bson.D{{Key: "id", Value: 1}}
+	for _, field := range fields {
+		projection = append(projection, bson.E{Key: field, Value: 1})
+	}
+
+	return m.findDocs(ctx, collection, bson.D{alive}, options.Find().SetProjection(projection))
+}
+
+// findDocs returns documents of collection matching filter, decoded into the type kept in collection.
+func (m *MongoDB) findDocs(ctx context.Context, collection string, filter bson.D, opts *options.FindOptions) ([]maco.Doc, error) {
+	col := m.client.Database(m.database).Collection(collection)
+
+	cur, err := col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding documents: %v", err)
+	}
+	defer cur.Close(ctx)
+
+	var docs []maco.Doc
+
+	for cur.Next(ctx) {
+		doc, err := newDoc(collection)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := cur.Decode(doc); err != nil {
+			return nil, fmt.Errorf("error decoding document: %v", err)
+		}
+
+		docs = append(docs, doc)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, fmt.Errorf("error decoding all documents: %v", err)
+	}
+
+	return docs, nil
+}
+
+// newDoc returns an empty document of the type kept in collection.
+func newDoc(collection string) (maco.Doc, error) {
+	switch collection {
+	case ColCharacters:
+		return &maco.Character{}, nil
+	case ColComics:
+		return &maco.Comic{}, nil
+	case ColCreators:
+		return &maco.Creator{}, nil
+	case ColEvents:
+		return &maco.Event{}, nil
+	case ColSeries:
+		return &maco.Series{}, nil
+	case ColStories:
+		return &maco.Story{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported collection: %s", collection)
+	}
+}
+
 func (m *MongoDB) SaveCharacters(ctx context.Context, chars []*maco.Character) error {
 	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
 	defer cancel()
@@ -222,6 +404,49 @@ func (m *MongoDB) saveMany(ctx context.Context, collection string, docs []maco.D
 	return nil
 }
 
+// ReplaceMany replaces fields of docs by id, inserting the ones not existing yet.
+func (m *MongoDB) ReplaceMany(ctx context.Context, collection string, docs []maco.Doc) error {
+	if len(docs) == 0 {
+		log.Info().Msg("no docs to replace")
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	// fetched fields are set rather than the whole document replaced, keeping mirrored images unless mirrored again,
+	// and the tombstone is removed from entities listed again
+	models := []mongo.WriteModel{}
+	for _, doc := range docs {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.D{{Key: "id", Value: doc.Identify()}}).
+			SetUpdate(bson.D{
+				{Key: "$set", Value: doc},
+				{Key: "$unset", Value: bson.D{{Key: "tombstone", Value: ""}}},
+			}).
+			SetUpsert(true),
+		)
+	}
+
+	col := m.client.Database(m.database).Collection(collection)
+	result, err := col.BulkWrite(ctx, models)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Int64("matched", result.MatchedCount).Int64("upserted", result.UpsertedCount).Msg("replaced docs")
+
+	m.cacheMu.Lock()
+	if ids, ok := m.cacheIDs[collection]; ok {
+		for _, doc := range diff(ids, docs) {
+			m.cacheIDs[collection] = append(m.cacheIDs[collection], doc.Identify())
+		}
+	}
+	m.cacheMu.Unlock()
+
+	return nil
+}
+
 func (m *MongoDB) SaveOne(ctx context.Context, doc maco.Doc) error {
 	var collection string
 	switch doc.(type) {
@@ -248,16 +473,66 @@ func (m *MongoDB) SaveOne(ctx context.Context, doc maco.Doc) error {
 
 	id := doc.Identify()
 
-	result, err := col.ReplaceOne(ctx, bson.D{{Key: "id", Value: id}}, doc)
+	// entities fetched by id may be missing, e.g. unlisted or referred to by others
+	result, err := col.ReplaceOne(ctx, bson.D{{Key: "id", Value: id}}, doc, options.Replace().SetUpsert(true))
 	if err != nil {
 		return err
 	}
 
+	if result.UpsertedCount > 0 {
+		m.cacheMu.Lock()
+		if ids, ok := m.cacheIDs[collection]; ok {
+			m.cacheIDs[collection] = append(ids, id)
+		}
+		m.cacheMu.Unlock()
+	}
+
 	log.Info().Interface("result", result).Int("id", id).Msgf("document %T(%d) replaced", doc, id)
 
 	return nil
 }
 
+// Tombstone marks documents of ids in collection as removed upstream.
+// Documents already tombstoned keep their tombstone.
+func (m *MongoDB) Tombstone(ctx context.Context, collection string, ids []int, tombstone *maco.Tombstone) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	col := m.client.Database(m.database).Collection(collection)
+
+	result, err := col.UpdateMany(ctx,
+		bson.D{{Key: "id", Value: bson.D{{Key: "$in", Value: ids}}}, alive},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "tombstone", Value: tombstone}}}},
+	)
+	if err != nil {
+		return fmt.Errorf("error tombstoning documents: %v", err)
+	}
+
+	log.Info().Str("collection", collection).Int64("count", result.ModifiedCount).Str("reason", tombstone.Reason).Msg("tombstoned documents")
+
+	return nil
+}
+
+// Revive removes tombstones of documents of ids in collection, e.g. entities listed again upstream.
+func (m *MongoDB) Revive(ctx context.Context, collection string, ids []int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	col := m.client.Database(m.database).Collection(collection)
+
+	result, err := col.UpdateMany(ctx,
+		bson.D{{Key: "id", Value: bson.D{{Key: "$in", Value: ids}}}},
+		bson.D{{Key: "$unset", Value: bson.D{{Key: "tombstone", Value: ""}}}},
+	)
+	if err != nil {
+		return fmt.Errorf("error reviving documents: %v", err)
+	}
+
+	log.Info().Str("collection", collection).Int64("count", result.ModifiedCount).Msg("revived documents")
+
+	return nil
+}
+
 func (m *MongoDB) getAllIds(ctx context.Context, collection string) ([]int, error) {
 	m.cacheMu.Lock()
 	defer m.cacheMu.Unlock()
@@ -2,7 +2,9 @@ package mongodb
 
 import (
 	"context"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/loivis/marvel-comics-api-data-loader/maco"
 )
@@ -106,6 +108,193 @@ func TestMongoDB_IncompleteIDs(t *testing.T) {
 	}
 }
 
+func TestMongoDB_SaveOne(t *testing.T) {
+	for _, tc := range []struct {
+		desc  string
+		saved []int
+		id    int
+		count int
+	}{
+		{
+			desc:  "Replace",
+			saved: []int{1, 2, 3},
+			id:    2,
+			count: 3,
+		},
+		{
+			desc:  "Insert",
+			saved: []int{1, 2, 3},
+			id:    4,
+			count: 4,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			m, err := New("mongodb://localhost:27017", "marvel_test")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			defer m.client.Database("marvel_test").Drop(context.Background())
+
+			var chars []*maco.Character
+			for _, id := range tc.saved {
+				chars = append(chars, &maco.Character{ID: id})
+			}
+
+			if err := m.SaveCharacters(context.Background(), chars); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := m.SaveOne(context.Background(), &maco.Character{ID: tc.id, Name: "foo", Intact: true}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			count, err := m.GetCount(context.Background(), ColCharacters)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, want := count, tc.count; got != want {
+				t.Errorf("got %d documents, want %d", got, want)
+			}
+
+			if got, want := len(m.cacheIDs[ColCharacters]), tc.count; got != want {
+				t.Errorf("got %d cached ids, want %d", got, want)
+			}
+
+			ids, err := m.IncompleteIDs(context.Background(), ColCharacters)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, want := len(ids), tc.count-1; got != want {
+				t.Errorf("got %d incomplete ids, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestMongoDB_ReplaceMany(t *testing.T) {
+	m, err := New("mongodb://localhost:27017", "marvel_test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	defer m.client.Database("marvel_test").Drop(context.Background())
+
+	mirrored := []*maco.ImageRef{{Source: "http://example.com/a.jpg", Variant: "portrait_xlarge", Key: "a"}}
+
+	err = m.SaveOne(context.Background(), &maco.Character{ID: 1, Name: "foo", Mirrored: mirrored, Tombstone: &maco.Tombstone{Reason: "unlisted"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = m.ReplaceMany(context.Background(), ColCharacters, []maco.Doc{&maco.Character{ID: 1, Name: "bar"}, &maco.Character{ID: 2, Name: "baz"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	docs, err := m.GetDocs(context.Background(), ColCharacters, "name", "mirrored", "tombstone")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := len(docs), 2; got != want {
+		t.Fatalf("got %d documents, want %d", got, want)
+	}
+
+	for _, doc := range docs {
+		if doc.Identify() != 1 {
+			continue
+		}
+
+		char := doc.(*maco.Character)
+
+		if got, want := char.Name, "bar"; got != want {
+			t.Errorf("got name %q, want %q", got, want)
+		}
+
+		if got, want := char.Mirrored, mirrored; !reflect.DeepEqual(got, want) {
+			t.Errorf("got mirrored %v, want %v", got, want)
+		}
+
+		if char.Tombstone != nil {
+			t.Errorf("got tombstone %v, want none", char.Tombstone)
+		}
+	}
+}
+
+func TestMongoDB_SyncMark(t *testing.T) {
+	m, err := New("mongodb://localhost:27017", "marvel_test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	defer m.client.Database("marvel_test").Drop(context.Background())
+
+	mark, err := m.GetSyncMark(context.Background(), "foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !mark.IsZero() {
+		t.Errorf("got mark %v before saved, want zero", mark)
+	}
+
+	for _, want := range []time.Time{
+		time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC),
+	} {
+		if err := m.SaveSyncMark(context.Background(), "foo", want); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		got, err := m.GetSyncMark(context.Background(), "foo")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !got.Equal(want) {
+			t.Errorf("got mark %v, want %v", got, want)
+		}
+	}
+}
+
+func TestMongoDB_Revive(t *testing.T) {
+	m, _, err := setupDatabase("marvel_test", "foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	defer m.client.Database("marvel_test").Drop(context.Background())
+
+	if err := m.Tombstone(context.Background(), "foo", []int{1, 2}, &maco.Tombstone{Reason: "unlisted"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := m.Revive(context.Background(), "foo", []int{1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ids, err := m.TombstonedIDs(context.Background(), "foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := ids, []int{2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got tombstoned ids %v, want %v", got, want)
+	}
+
+	count, err := m.GetCount(context.Background(), "foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := count, 76; got != want {
+		t.Errorf("got count %d, want %d", got, want)
+	}
+}
+
 func setupDatabase(database, collection string) (*MongoDB, []interface{}, error) {
 	m, err := New("mongodb://localhost:27017", database)
 	if err != nil {
@@ -0,0 +1,100 @@
+package process
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/avast/retry-go"
+	"github.com/rs/zerolog/log"
+
+	"github.com/loivis/marvel-comics-api-data-loader/maco"
+)
+
+// orderBy is the order all entities are paged in.
+// An entity modified while paging moves to the end and shifts those after it back by one offset,
+// so pages are resumed from the saved page before each unsaved one.
+// More entities modified than a page holds still slip past, which verify reports and enumerate finds.
+const orderBy = "modified"
+
+// checkpoint returns the checkpoint of paging over e, resuming from the local count if none is saved yet.
+func (p *Processor) checkpoint(ctx context.Context, e *entity, existing int) (*maco.Checkpoint, error) {
+	cp, err := p.store.GetCheckpoint(ctx, e.typ, orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case cp == nil:
+	case cp.Limit != p.limit:
+		log.Warn().Str("type", e.typ).Int("limit", cp.Limit).Msg("ignored checkpoint of different page size")
+	case firstUnsaved(savedOffsets(cp), cp.Limit) >= cp.Total:
+		// only an interrupted run is resumed, pages of a finished one have shifted since
+		log.Info().Str("type", e.typ).Time("updated", cp.Updated).Msg("ignored checkpoint of finished run")
+	default:
+		log.Info().Str("type", e.typ).Int("pages", len(cp.Offsets)).Msg("resume from checkpoint")
+		return cp, nil
+	}
+
+	// without a checkpoint, pages below the local count are taken as saved
+	cp = &maco.Checkpoint{Collection: e.typ, OrderBy: orderBy, Limit: p.limit}
+	for offset := 0; offset < existing/p.limit*p.limit; offset += p.limit {
+		cp.Offsets = append(cp.Offsets, offset)
+	}
+
+	return cp, nil
+}
+
+// saveCheckpoint adds offsets of pages just saved to cp and saves it.
+func (p *Processor) saveCheckpoint(ctx context.Context, cp *maco.Checkpoint, offsets []int) error {
+	saved := savedOffsets(cp)
+	for _, offset := range offsets {
+		if !saved[offset] { // pages before unsaved ones are fetched again
+			cp.Offsets = append(cp.Offsets, offset)
+			saved[offset] = true
+		}
+	}
+	sort.Ints(cp.Offsets)
+	cp.Updated = time.Now().UTC()
+
+	return retry.Do(func() error {
+		return p.store.SaveCheckpoint(ctx, cp)
+	}, retry.RetryIf(func(error) bool { return ctx.Err() == nil }))
+}
+
+// savedOffsets returns offsets of saved pages in cp as a set.
+func savedOffsets(cp *maco.Checkpoint) map[int]bool {
+	saved := make(map[int]bool)
+	for _, offset := range cp.Offsets {
+		saved[offset] = true
+	}
+
+	return saved
+}
+
+// firstUnsaved returns the lowest offset of a page not saved.
+func firstUnsaved(saved map[int]bool, limit int) int {
+	offset := 0
+	for saved[offset] {
+		offset += limit
+	}
+
+	return offset
+}
+
+// resumeOffset returns the offset to resume paging from, the page before the first one not saved.
+func resumeOffset(saved map[int]bool, limit int) int {
+	offset := firstUnsaved(saved, limit)
+	if offset == 0 {
+		return 0
+	}
+
+	return offset - limit
+}
+
+// skipSaved returns whether the page at offset is skipped, i.e. saved and followed by a saved page or the end of total.
+func skipSaved(saved map[int]bool, limit, total int) func(offset int) bool {
+	return func(offset int) bool {
+		return saved[offset] && (saved[offset+limit] || offset+limit >= total)
+	}
+}
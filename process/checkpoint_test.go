@@ -0,0 +1,127 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/loivis/marvel-comics-api-data-loader/client/marvel"
+	"github.com/loivis/marvel-comics-api-data-loader/maco"
+)
+
+func TestProcessor_LoadAllWithBasicInfo(t *testing.T) {
+	var mu sync.Mutex
+	calls := make(map[int]int)
+	failing := true
+
+	// 6 characters in pages of 2, the last page fails until failing is unset
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+		mu.Lock()
+		calls[offset]++
+		fail := failing && offset == 4
+		mu.Unlock()
+
+		if fail {
+			http.NotFound(w, r)
+			return
+		}
+
+		fmt.Fprintf(w, `{"data":{"offset":%d,"limit":2,"total":6,"count":2,"results":[%s,%s]}}`, offset, character(offset+1), character(offset+2))
+	}))
+	defer ts.Close()
+
+	store := &memStore{}
+
+	p := NewProcessor(marvel.NewClient(ts.URL, "", "public"), store, "", "")
+	p.limit = 2
+	p.storeBatch = 1
+	p.concurrency = 1
+
+	err := p.loadAllWithBasicInfo(context.Background(), characterEntity)
+	if err == nil {
+		t.Fatal("error is nil")
+	}
+
+	if got, want := store.checkpoint.Offsets, []int{0, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got saved offsets %v, want %v", got, want)
+	}
+
+	failing = false
+	calls = make(map[int]int)
+
+	err = p.loadAllWithBasicInfo(context.Background(), characterEntity)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	// the saved page before the failed one is fetched again for entities shifted back
+	if got, want := calls, map[int]int{2: 1, 4: 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got calls %v, want %v", got, want)
+	}
+
+	if got, want := store.checkpoint.Offsets, []int{0, 2, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got saved offsets %v, want %v", got, want)
+	}
+
+	if got, want := store.ids(maco.TypeCharacters), []int{1, 2, 3, 4, 5, 6}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got saved ids %v, want %v", got, want)
+	}
+}
+
+func TestProcessor_Checkpoint(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		stored   *maco.Checkpoint
+		existing int
+		want     []int
+	}{
+		{
+			desc:     "None",
+			existing: 5,
+			want:     []int{0, 2},
+		},
+		{
+			desc:     "DifferentLimit",
+			stored:   &maco.Checkpoint{Limit: 3, Offsets: []int{0, 3}, Total: 9},
+			existing: 5,
+			want:     []int{0, 2},
+		},
+		{
+			desc:     "Interrupted",
+			stored:   &maco.Checkpoint{Limit: 2, Offsets: []int{0, 2, 6}, Total: 8},
+			existing: 7,
+			want:     []int{0, 2, 6},
+		},
+		{
+			desc:     "Finished",
+			stored:   &maco.Checkpoint{Limit: 2, Offsets: []int{0, 2, 4}, Total: 6},
+			existing: 3,
+			want:     []int{0},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			p := NewProcessor(marvel.NewClient("", "", "public"), &memStore{checkpoint: tc.stored}, "", "")
+			p.limit = 2
+
+			cp, err := p.checkpoint(context.Background(), characterEntity, tc.existing)
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			if got, want := cp.Offsets, tc.want; !reflect.DeepEqual(got, want) {
+				t.Errorf("got offsets %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func character(id int) string {
+	return fmt.Sprintf(`{"id":%d,"thumbnail":{},"comics":{},"events":{},"series":{},"stories":{}}`, id)
+}
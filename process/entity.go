@@ -0,0 +1,423 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/avast/retry-go"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/loivis/marvel-comics-api-data-loader/client/marvel"
+	"github.com/loivis/marvel-comics-api-data-loader/maco"
+)
+
+// entity describes a type of marvel entities, so that all types are loaded by the same pipeline.
+type entity struct {
+	typ  string // collection and api path, e.g. maco.TypeCharacters
+	name string // singular name in logs, e.g. "character"
+
+	// list fetches a page of entities.
+	list func(ctx context.Context, mc *marvel.Client, params *marvel.Params) (marvel.ListPage, error)
+	// get fetches a single entity by id.
+	get func(ctx context.Context, mc *marvel.Client, id int) (interface{}, error)
+	// results returns entities in a page returned by list.
+	results func(page marvel.ListPage) []interface{}
+	// convert converts an entity returned by get or list into a document.
+	convert func(p *Processor, in interface{}) (maco.Doc, error)
+	// relations returns sub-resources of an entity along with ids of them in the converted document.
+	relations func(in interface{}, doc maco.Doc) []*relation
+	// save saves a batch of converted documents.
+	save func(ctx context.Context, store maco.Store, docs []maco.Doc) error
+
+	// onDuplicate, if set, is called with ids found more than once while paging.
+	onDuplicate func(p *Processor, id int)
+}
+
+// relation is a sub-resource of an entity, e.g. comics of a character.
+type relation struct {
+	typ       string // type of related entities, e.g. maco.TypeComics
+	available int    // count of related entities
+	returned  int    // count of related entities embedded in the entity
+	ids       *[]int // ids of related entities in the converted document
+}
+
+// entities lists all entities by type.
+var entities = map[string]*entity{
+	maco.TypeCharacters: characterEntity,
+	maco.TypeComics:     comicEntity,
+	maco.TypeCreators:   creatorEntity,
+	maco.TypeEvents:     eventEntity,
+	maco.TypeSeries:     seriesEntity,
+	maco.TypeStories:    storyEntity,
+}
+
+func entityOf(typ string) (*entity, error) {
+	e, ok := entities[typ]
+	if !ok {
+		return nil, fmt.Errorf("unsupported type: %s", typ)
+	}
+
+	return e, nil
+}
+
+func (p *Processor) loadAllWithBasicInfo(ctx context.Context, e *entity) error {
+	existing, err := p.store.GetCount(ctx, e.typ)
+	if err != nil {
+		return err
+	}
+	log.Info().Str("type", e.name).Int("count", existing).Msgf("existing %s count", e.name)
+
+	cp, err := p.checkpoint(ctx, e, existing)
+	if err != nil {
+		return fmt.Errorf("error getting %s checkpoint: %v", e.name, err)
+	}
+
+	saved := savedOffsets(cp)
+
+	pager := p.pager(resumeOffset(saved, p.limit))
+	if e.onDuplicate != nil {
+		pager.OnDuplicate = func(id int) { e.onDuplicate(p, id) }
+	}
+
+	// the page holding the first missing entity also tells the remote count
+	first, err := pager.Fetch(ctx, p.fetchAll(e), pager.Offset)
+	if err != nil {
+		return fmt.Errorf("error fetching %s count: %v", e.name, err)
+	}
+
+	remote := first.Envelope().Total
+	log.Info().Str("type", e.name).Int("count", remote).Msgf("%s count from api", e.name)
+
+	if remote == existing || firstUnsaved(saved, p.limit) >= remote {
+		log.Info().Int("local", existing).Int("remote", remote).Msgf("no missing %s", e.typ)
+		return nil
+	}
+
+	log.Info().Int("local", existing).Int("remote", remote).Int("offset", pager.Offset).Msgf("missing %s, reload", e.typ)
+
+	cp.Total = remote
+	pager.Skip = skipSaved(saved, p.limit, remote)
+
+	return p.loadMissing(ctx, e, cp, pager, first)
+}
+
+// fetchAll returns a marvel.PageFunc over all entities of e.
+func (p *Processor) fetchAll(e *entity) marvel.PageFunc {
+	return func(ctx context.Context, offset, limit int) (marvel.ListPage, error) {
+		return e.list(ctx, p.mclient, &marvel.Params{Limit: limit, Offset: offset, OrderBy: orderBy})
+	}
+}
+
+// pageDocs are documents converted from the page at offset.
+type pageDocs struct {
+	offset int
+	docs   []maco.Doc
+}
+
+// loadMissing saves entities of pages walked by pager in batches, and saves offsets of pages in cp after each batch.
+func (p *Processor) loadMissing(ctx context.Context, e *entity, cp *maco.Checkpoint, pager *marvel.Pager, first marvel.ListPage) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pageCh := make(chan *pageDocs, p.concurrency)
+	errCh := make(chan error, 1)
+	doneCh := make(chan struct{})
+
+	go func() {
+		var docs []maco.Doc
+		var offsets []int
+		defer close(doneCh)
+
+		batchSave := func(docs []maco.Doc, offsets []int) error {
+			if len(docs) > 0 {
+				err := retry.Do(func() error {
+					return e.save(ctx, p.store, docs)
+				}, retry.RetryIf(func(error) bool { return ctx.Err() == nil }))
+
+				if err != nil {
+					return err
+				}
+
+				log.Info().Int("count", len(docs)).Msgf("batch saved %s", e.typ)
+			}
+
+			if len(offsets) == 0 {
+				return nil
+			}
+
+			if err := p.saveCheckpoint(ctx, cp, offsets); err != nil {
+				return fmt.Errorf("error saving %s checkpoint: %v", e.name, err)
+			}
+
+			return nil
+		}
+
+		failed := false
+
+		for page := range pageCh {
+			if failed { // keep receiving so that walking is never blocked
+				continue
+			}
+
+			docs = append(docs, page.docs...)
+			offsets = append(offsets, page.offset)
+
+			if len(docs) >= p.storeBatch {
+				if err := batchSave(docs, offsets); err != nil {
+					errCh <- err
+					failed = true
+					continue
+				}
+				docs, offsets = []maco.Doc{}, []int{}
+			}
+		}
+
+		if failed {
+			return
+		}
+
+		if err := batchSave(docs, offsets); err != nil {
+			errCh <- err
+		}
+	}()
+
+	err := pager.WalkFrom(ctx, first, p.fetchAll(e), func(page marvel.ListPage) error {
+		select {
+		case err := <-errCh: // check if any error saving data
+			return fmt.Errorf("cancelled fetching paged %s: %v", e.typ, err)
+		default: // default to avoid blocking
+		}
+
+		docs, err := p.convertPage(e, page)
+		if err != nil {
+			return err
+		}
+
+		pageCh <- &pageDocs{offset: page.Envelope().Offset, docs: docs}
+
+		log.Info().Int("offset", page.Envelope().Offset).Int("count", len(docs)).Msgf("fetched paged %s", e.typ)
+
+		return nil
+	})
+
+	// pages walked before any error are still saved along with their offsets
+	close(pageCh)
+	<-doneCh
+
+	if err != nil {
+		return fmt.Errorf("error fetching paged %s: %v", e.typ, err)
+	}
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("error saving paged %s: %v", e.typ, err)
+	default:
+	}
+
+	log.Info().Msgf("fetched all missing %s with basic info", e.typ)
+
+	return nil
+}
+
+// convertPage converts results of a page of e into documents.
+func (p *Processor) convertPage(e *entity, page marvel.ListPage) ([]maco.Doc, error) {
+	var docs []maco.Doc
+
+	for _, in := range e.results(page) {
+		converted, err := e.convert(p, in)
+		if err != nil {
+			return nil, err
+		}
+
+		recordAvailable(converted, e.relations(in, converted))
+
+		docs = append(docs, converted)
+	}
+
+	return docs, nil
+}
+
+func (p *Processor) complementAll(ctx context.Context, e *entity) error {
+	ids, err := p.store.IncompleteIDs(ctx, e.typ)
+	if err != nil {
+		return fmt.Errorf("error get imcomplete %s ids: %v", e.name, err)
+	}
+
+	if len(ids) == 0 {
+		log.Info().Msgf("no incomplete %s", e.name)
+		return nil
+	}
+
+	log.Info().Int("count", len(ids)).Msgf("fetched incomplete %s ids", e.name)
+
+	return p.complementIDs(ctx, e, ids)
+}
+
+// complementIDs fetches entities of e with ids along with all their sub-resources, and saves them.
+func (p *Processor) complementIDs(ctx context.Context, e *entity, ids []int) error {
+	var g errgroup.Group
+
+	conCh := make(chan struct{}, p.concurrency)
+
+	for _, id := range ids {
+		conCh <- struct{}{}
+
+		id := id
+
+		g.Go(func() error {
+			defer func() {
+				<-conCh
+			}()
+
+			// calls for one entity take turns with those for others
+			ctx := marvel.WithOwner(ctx, fmt.Sprintf("%s/%d", e.typ, id))
+
+			doc, err := p.getWithFullInfo(ctx, e, id)
+			var nf *marvel.NotFoundError
+			if errors.As(err, &nf) {
+				log.Warn().Int("id", id).Msgf("%s not found", e.name)
+				return p.tombstone(ctx, e, []int{id}, reasonNotFound)
+			}
+			if err != nil {
+				return fmt.Errorf("error fetching %s %d: %v", e.name, id, err)
+			}
+
+			log.Info().Int("id", id).Msgf("fetched %s with full info converted", e.name)
+
+			err = p.store.SaveOne(ctx, doc)
+			if err != nil {
+				return fmt.Errorf("error saving %s %d: %v", e.name, id, err)
+			}
+
+			log.Info().Int("id", id).Msgf("complemented %s", e.name)
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return fmt.Errorf("error complementing %s: %v", e.typ, err)
+	}
+
+	log.Info().Int("count", len(ids)).Msgf("complemented %s", e.typ)
+
+	return nil
+}
+
+func (p *Processor) getWithFullInfo(ctx context.Context, e *entity, id int) (maco.Doc, error) {
+	in, err := e.get(ctx, p.mclient, id)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s %d: %w", e.name, id, err)
+	}
+
+	log.Info().Int("id", id).Msgf("fetched %s with basic info", e.name)
+
+	doc, err := e.convert(p, in)
+	if err != nil {
+		return nil, fmt.Errorf("error converting %s %d: %v", e.name, id, err)
+	}
+
+	relations := e.relations(in, doc)
+	recordAvailable(doc, relations)
+
+	for _, r := range relations {
+		if r.available == r.returned {
+			log.Info().Int("id", id).Int("count", r.available).Msgf("%s has complete %s", e.name, r.typ)
+			continue
+		}
+
+		ids, total, err := p.getRelated(ctx, e, id, r.typ)
+		if err != nil {
+			return nil, err
+		}
+
+		/*
+			report instead of verifying here AS responses differ between
+			available returned from /v1/public/{type}/{id}
+			and
+			total returned from /v1/public/{type}/{id}/{subtype}
+		*/
+		p.report.countMismatch(e.typ, id, r.typ, r.available, total)
+
+		*r.ids = ids
+	}
+
+	markIntact(doc)
+
+	return doc, nil
+}
+
+// getRelated returns ids of all entities of typ related to the entity of e and id,
+// and the total the api counts of them, which ids may fall short of after duplicates are dropped.
+func (p *Processor) getRelated(ctx context.Context, e *entity, id int, typ string) ([]int, int, error) {
+	fetch := func(ctx context.Context, offset, limit int) (marvel.ListPage, error) {
+		return p.mclient.GetRelated(ctx, e.typ, id, typ, &marvel.Params{Limit: limit, Offset: offset, OrderBy: "modified"})
+	}
+
+	pager := p.pager(0)
+
+	first, err := pager.Fetch(ctx, fetch, pager.Offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error fetching %s for %s %d: %v", typ, e.name, id, err)
+	}
+
+	var ids []int
+
+	err = pager.WalkFrom(ctx, first, fetch, func(page marvel.ListPage) error {
+		ids = append(ids, page.IDs()...)
+
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("error fetching %s for %s %d: %v", typ, e.name, id, err)
+	}
+
+	total := first.Envelope().Total
+
+	log.Info().Int("count", len(ids)).Int("total", total).Int(e.name+"_id", id).Msgf("fetched %s for %s", typ, e.name)
+
+	return ids, total, nil
+}
+
+// recordAvailable keeps available counts of relations in doc, to tell how many ids are missing without fetching it again.
+func recordAvailable(doc maco.Doc, relations []*relation) {
+	available := make(map[string]int, len(relations))
+	for _, r := range relations {
+		available[r.typ] = r.available
+	}
+
+	switch v := doc.(type) {
+	case *maco.Character:
+		v.Available = available
+	case *maco.Comic:
+		v.Available = available
+	case *maco.Creator:
+		v.Available = available
+	case *maco.Event:
+		v.Available = available
+	case *maco.Series:
+		v.Available = available
+	case *maco.Story:
+		v.Available = available
+	}
+}
+
+// markIntact marks doc as holding all of its related ids.
+func markIntact(doc maco.Doc) {
+	switch v := doc.(type) {
+	case *maco.Character:
+		v.Intact = true
+	case *maco.Comic:
+		v.Intact = true
+	case *maco.Creator:
+		v.Intact = true
+	case *maco.Event:
+		v.Intact = true
+	case *maco.Series:
+		v.Intact = true
+	case *maco.Story:
+		v.Intact = true
+	}
+}
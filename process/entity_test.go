@@ -0,0 +1,95 @@
+package process
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/loivis/marvel-comics-api-data-loader/client/marvel"
+	"github.com/loivis/marvel-comics-api-data-loader/maco"
+)
+
+func TestProcessor_GetWithFullInfo(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/characters/1":
+			w.Write([]byte(`{"data":{"results":[{
+				"id": 1,
+				"thumbnail": {"path": "http://example.com/a", "extension": "jpg"},
+				"comics": {"available": 3, "returned": 1, "items": [{"resourceURI": "http://example.com/comics/11"}]},
+				"events": {"available": 1, "returned": 1, "items": [{"resourceURI": "http://example.com/events/21"}]},
+				"series": {"available": 2, "returned": 0},
+				"stories": {"available": 0, "returned": 0}
+			}]}}`))
+		case "/characters/1/comics":
+			w.Write([]byte(`{"data":{"total":3,"count":3,"results":[{"id":11},{"id":12},{"id":13}]}}`))
+		case "/characters/1/series":
+			// one more than available, and one listed twice
+			w.Write([]byte(`{"data":{"total":3,"count":3,"results":[{"id":31},{"id":32},{"id":32}]}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	p := NewProcessor(marvel.NewClient(ts.URL, "", "public"), nil, "", "")
+
+	doc, err := p.getWithFullInfo(context.Background(), characterEntity, 1)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	char, ok := doc.(*maco.Character)
+	if !ok {
+		t.Fatalf("got doc type %T, want %T", doc, &maco.Character{})
+	}
+
+	if got, want := char.Comics, []int{11, 12, 13}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got comics %v, want %v", got, want)
+	}
+
+	if got, want := char.Events, []int{21}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got events %v, want %v", got, want)
+	}
+
+	if got, want := char.Series, []int{31, 32}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got series %v, want %v", got, want)
+	}
+
+	if !char.Intact {
+		t.Error("got character not intact")
+	}
+
+	want := []*CountMismatch{{Type: maco.TypeCharacters, ID: 1, List: maco.TypeSeries, Available: 2, Total: 3}}
+	if got := p.report.CountMismatches; !reflect.DeepEqual(got, want) {
+		t.Errorf("got count mismatches %v, want %v", got, want)
+	}
+}
+
+func TestProcessor_ConvertSeries(t *testing.T) {
+	p := NewProcessor(nil, nil, "", "")
+
+	out, err := p.convertSeries(&marvel.Series{
+		Characters: &marvel.CharacterList{},
+		Comics:     &marvel.ComicList{},
+		Creators:   &marvel.CreatorList{},
+		Events:     &marvel.EventList{},
+		Next:       &marvel.SeriesSummary{ResourceURI: "http://example.com/series/3"},
+		Previous:   &marvel.SeriesSummary{ResourceURI: "http://example.com/series/1"},
+		Stories:    &marvel.StoryList{},
+		Thumbnail:  &marvel.Image{},
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if got, want := out.Next, 3; got != want {
+		t.Errorf("got next %d, want %d", got, want)
+	}
+
+	if got, want := out.Previous, 1; got != want {
+		t.Errorf("got previous %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,275 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/loivis/marvel-comics-api-data-loader/client/marvel"
+)
+
+/*
+	paging over a whole collection is not stable, a page at the same offset may return
+	other entities the next time, so entities are enumerated in windows of modified time instead.
+	the count of a window is told by totals of listing with modifiedSince at both ends,
+	and windows are split until each fits in a page and returns as many ids as it counts.
+	counts of all windows add up to the total of the collection,
+	so no short window left means no entity is missed.
+*/
+
+// epoch is the lowest bound windows are split at.
+// Entities modified before, or with malformed modified, are enumerated in a single window.
+var epoch = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// end is the upper bound of the window of entities modified while enumerating,
+// which are not to be taken as removed because they moved out of windows already fetched.
+var end = time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// windowAttempts is how many times a window which cannot be split is fetched before it is reported short.
+const windowAttempts = 3
+
+// Window is a range of modified time of a collection.
+type Window struct {
+	Since time.Time `json:"since"` // inclusive, zero for the beginning
+	Until time.Time `json:"until"` // exclusive
+	Count int       `json:"count"` // count of entities in the window told by api
+	Got   int       `json:"got"`   // count of ids fetched in the window
+
+	from int // total modified since Since
+	to   int // total modified since Until
+}
+
+func (w *Window) String() string {
+	return fmt.Sprintf("%s - %s: count %d, got %d", w.Since.Format(time.RFC3339), w.Until.Format(time.RFC3339), w.Count, w.Got)
+}
+
+// Enumeration is the set of ids of a collection, enumerated in windows of modified time.
+type Enumeration struct {
+	Type  string    `json:"type"`
+	Total int       `json:"total"` // count of the collection told by api
+	IDs   []int     `json:"-"`     // in ascending order
+	Short []*Window `json:"short"` // windows with less ids than counted
+}
+
+// Complete returns whether every window returned as many ids as counted,
+// that is ids of all entities in the collection are enumerated.
+func (en *Enumeration) Complete() bool {
+	return len(en.Short) == 0
+}
+
+// enumerate enumerates ids of all entities of e.
+func (p *Processor) enumerate(ctx context.Context, e *entity) (*Enumeration, error) {
+	until := time.Now().UTC().Truncate(time.Second)
+
+	total, err := p.countSince(ctx, e, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	since, err := p.countSince(ctx, e, epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := p.countSince(ctx, e, until)
+	if err != nil {
+		return nil, err
+	}
+
+	en := &Enumeration{Type: e.typ, Total: total}
+	ids := make(map[int]bool)
+
+	windows := []*Window{
+		{Until: epoch, from: total, to: since},
+		{Since: epoch, Until: until, from: since, to: after},
+		{Since: until, Until: end, from: after},
+	}
+
+	for len(windows) > 0 {
+		w := windows[len(windows)-1]
+		windows = windows[:len(windows)-1]
+
+		w.Count = w.from - w.to
+		if w.Count <= 0 {
+			continue
+		}
+
+		if w.Count <= p.limit || !splittable(w) {
+			got, err := p.fetchWindow(ctx, e, w)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, id := range got {
+				ids[id] = true
+			}
+
+			if w.Got >= w.Count {
+				continue
+			}
+
+			if !splittable(w) {
+				log.Warn().Str("type", e.typ).Msgf("short window %s", w)
+				en.Short = append(en.Short, w)
+				continue
+			}
+		}
+
+		mid := w.Since.Add(w.Until.Sub(w.Since) / 2).Truncate(time.Second)
+
+		n, err := p.countSince(ctx, e, mid)
+		if err != nil {
+			return nil, err
+		}
+
+		// the later half is popped first, so windows are fetched in order of modified
+		windows = append(windows,
+			&Window{Since: mid, Until: w.Until, from: n, to: w.to},
+			&Window{Since: w.Since, Until: mid, from: w.from, to: n},
+		)
+	}
+
+	for id := range ids {
+		en.IDs = append(en.IDs, id)
+	}
+	sort.Ints(en.IDs)
+
+	log.Info().Str("type", e.typ).Int("total", total).Int("count", len(en.IDs)).Bool("complete", en.Complete()).Msg("enumerated ids")
+
+	return en, nil
+}
+
+// splittable returns whether w can be split into two windows of at least a second.
+func splittable(w *Window) bool {
+	return !w.Since.IsZero() && w.Until.Sub(w.Since) >= 2*time.Second
+}
+
+// countSince returns the count of entities of e modified since t, or all of them if t is zero.
+func (p *Processor) countSince(ctx context.Context, e *entity, t time.Time) (int, error) {
+	page, err := p.pager(0).Fetch(ctx, func(ctx context.Context, offset, limit int) (marvel.ListPage, error) {
+		return e.list(ctx, p.mclient, &marvel.Params{Limit: 1, ModifiedSince: t})
+	}, 0)
+	if err != nil {
+		return 0, fmt.Errorf("error counting %s modified since %v: %v", e.typ, t, err)
+	}
+
+	return page.Envelope().Total, nil
+}
+
+// errWindowEnd stops paging at the first entity modified after a window.
+var errWindowEnd = errors.New("end of window")
+
+// fetchWindow returns ids of entities of e in w, and counts them in w.Got.
+// Windows which cannot be split are fetched up to windowAttempts times until all ids are got.
+func (p *Processor) fetchWindow(ctx context.Context, e *entity, w *Window) ([]int, error) {
+	got := make(map[int]bool)
+
+	attempts := 1
+	if !splittable(w) {
+		attempts = windowAttempts
+	}
+
+	for i := 0; i < attempts && len(got) < w.Count; i++ {
+		pager := p.pager(0)
+		pager.Concurrency = 1
+
+		err := pager.Walk(ctx, func(ctx context.Context, offset, limit int) (marvel.ListPage, error) {
+			return e.list(ctx, p.mclient, &marvel.Params{Limit: limit, Offset: offset, OrderBy: orderBy, ModifiedSince: w.Since})
+		}, func(page marvel.ListPage) error {
+			docs, err := p.convertPage(e, page)
+			if err != nil {
+				return err
+			}
+
+			for _, doc := range docs {
+				if !modifiedOf(doc).Before(w.Until) {
+					return errWindowEnd
+				}
+
+				got[doc.Identify()] = true
+			}
+
+			return nil
+		})
+		if err != nil && err != errWindowEnd {
+			return nil, fmt.Errorf("error fetching %s window %s: %v", e.typ, w, err)
+		}
+	}
+
+	var ids []int
+	for id := range got {
+		ids = append(ids, id)
+	}
+
+	w.Got = len(ids)
+
+	return ids, nil
+}
+
+// loadUnlisted enumerates ids of e and loads entities missing in store with full info.
+// Entities in store but not enumerated are tombstoned if the enumeration is complete,
+// and tombstoned entities enumerated again are revived and loaded again.
+func (p *Processor) loadUnlisted(ctx context.Context, e *entity) error {
+	en, err := p.enumerate(ctx, e)
+	if err != nil {
+		return err
+	}
+
+	p.report.enumeration(en)
+
+	local, err := p.store.GetIDs(ctx, e.typ)
+	if err != nil {
+		return fmt.Errorf("error getting local %s ids: %v", e.name, err)
+	}
+
+	tombstoned, err := p.store.TombstonedIDs(ctx, e.typ)
+	if err != nil {
+		return fmt.Errorf("error getting tombstoned %s ids: %v", e.name, err)
+	}
+
+	// only a complete enumeration tells local ids not listed are of entities removed upstream
+	if en.Complete() {
+		if err := p.tombstone(ctx, e, subtract(subtract(local, en.IDs), tombstoned), reasonUnlisted); err != nil {
+			return err
+		}
+	} else {
+		log.Warn().Str("type", e.typ).Int("short", len(en.Short)).Msgf("enumeration incomplete, removed %s not detected", e.name)
+	}
+
+	// e.g. tombstoned on a one-off 404 or a single run not listing them
+	relisted := subtract(en.IDs, subtract(en.IDs, tombstoned))
+	if err := p.revive(ctx, e, relisted); err != nil {
+		return err
+	}
+
+	missing := append(subtract(en.IDs, local), relisted...)
+	if len(missing) == 0 {
+		log.Info().Str("type", e.typ).Msgf("no unlisted %s", e.name)
+		return nil
+	}
+
+	log.Info().Str("type", e.typ).Int("count", len(missing)).Msgf("loading unlisted %s", e.name)
+
+	return p.complementIDs(ctx, e, missing)
+}
+
+// subtract returns ids in a but not in b.
+func subtract(a, b []int) []int {
+	in := make(map[int]bool, len(b))
+	for _, id := range b {
+		in[id] = true
+	}
+
+	var out []int
+	for _, id := range a {
+		if !in[id] {
+			out = append(out, id)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,63 @@
+package process
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/loivis/marvel-comics-api-data-loader/client/marvel/marveltest"
+)
+
+func TestProcessor_Enumerate(t *testing.T) {
+	// characters 1 to 7 modified an hour apart, listed with the previous page instead of every page after the first
+	ids := []int{1, 2, 3, 4, 5, 6, 7}
+
+	t.Run("Complete", func(t *testing.T) {
+		s := characterServer(t, ids, &marveltest.Fault{Duplicate: true})
+		defer s.Close()
+
+		p := NewProcessor(clientOf(s), nil, "", "")
+		p.limit = 2
+
+		en, err := p.enumerate(context.Background(), characterEntity)
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if got, want := en.IDs, ids; !reflect.DeepEqual(got, want) {
+			t.Errorf("got ids %v, want %v", got, want)
+		}
+
+		if !en.Complete() {
+			t.Errorf("got short windows %v", en.Short)
+		}
+	})
+
+	t.Run("Short", func(t *testing.T) {
+		// character 3 counted but never listed
+		s := characterServer(t, ids, &marveltest.Fault{Duplicate: true, Hide: []int{3}})
+		defer s.Close()
+
+		p := NewProcessor(clientOf(s), nil, "", "")
+		p.limit = 2
+
+		en, err := p.enumerate(context.Background(), characterEntity)
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		if got, want := en.IDs, []int{1, 2, 4, 5, 6, 7}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got ids %v, want %v", got, want)
+		}
+
+		if got, want := len(en.Short), 1; got != want {
+			t.Fatalf("got %d short windows, want %d", got, want)
+		}
+
+		w := en.Short[0]
+
+		if modified := modifiedAt(3); w.Since.After(modified) || !w.Until.After(modified) {
+			t.Errorf("got short window %s, want one holding %v", w, modified)
+		}
+	})
+}
@@ -0,0 +1,274 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/loivis/marvel-comics-api-data-loader/maco"
+)
+
+/*
+	a dry run tells what a run would do before spending any quota on it.
+	remote totals cost one api call per type, everything else is read from the store,
+	and nothing is written. calls of phases depending on what earlier phases load,
+	e.g. sub-resources of entities not yet in store, are estimated at their lower bound.
+*/
+
+// maxReturned is the most ids returned in a list of related entities, e.g. comics of a character.
+const maxReturned = 20
+
+// Estimate is the plan of a run, with api calls estimated per phase of each type.
+type Estimate struct {
+	Types []*TypeEstimate
+}
+
+// TypeEstimate is the plan of one type.
+type TypeEstimate struct {
+	Type       string
+	Remote     int            // total from api
+	Local      int            // documents in store, tombstoned left out
+	Incomplete int            // documents not marked intact
+	Calls      map[string]int // estimated api calls of each phase to run
+}
+
+// Behind returns how many entities are missing in store.
+func (te *TypeEstimate) Behind() int {
+	return te.Remote - te.Local
+}
+
+// Calls returns estimated api calls of all types and phases.
+func (est *Estimate) Calls() int {
+	var n int
+	for _, te := range est.Types {
+		for _, calls := range te.Calls {
+			n += calls
+		}
+	}
+
+	return n
+}
+
+func (est *Estimate) String() string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 1, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprint(w, "type\tremote\tlocal\tbehind\tincomplete\t")
+	for _, phase := range phases {
+		fmt.Fprintf(w, "%s\t", phase)
+	}
+	fmt.Fprintln(w)
+
+	for _, te := range est.Types {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t", te.Type, te.Remote, te.Local, te.Behind(), te.Incomplete)
+		for _, phase := range phases {
+			if calls, ok := te.Calls[phase]; ok {
+				fmt.Fprintf(w, "%d\t", calls)
+			} else {
+				fmt.Fprint(w, "-\t")
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	w.Flush()
+
+	fmt.Fprintf(&buf, "estimated api calls: %d\n", est.Calls())
+
+	return buf.String()
+}
+
+// DryRun estimates api calls of each phase of selected types without loading anything.
+func (p *Processor) DryRun(ctx context.Context) (*Estimate, error) {
+	types, run, err := p.plan()
+	if err != nil {
+		return nil, err
+	}
+
+	est := &Estimate{}
+
+	for _, typ := range types {
+		e, err := entityOf(typ)
+		if err != nil {
+			return nil, err
+		}
+
+		te, err := p.estimate(ctx, e, run)
+		if err != nil {
+			return nil, p.checkQuota(fmt.Errorf("error estimating %s: %v", typ, err))
+		}
+
+		log.Info().Str("type", typ).Int("remote", te.Remote).Int("local", te.Local).Int("incomplete", te.Incomplete).Msgf("estimated %s", typ)
+
+		est.Types = append(est.Types, te)
+	}
+
+	return est, nil
+}
+
+// estimate returns the plan of e with api calls of phases in run.
+func (p *Processor) estimate(ctx context.Context, e *entity, run map[string]bool) (*TypeEstimate, error) {
+	remote, err := p.countSince(ctx, e, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	local, err := p.store.GetCount(ctx, e.typ)
+	if err != nil {
+		return nil, fmt.Errorf("error getting local %s count: %v", e.name, err)
+	}
+
+	docs, err := p.store.IncompleteDocs(ctx, e.typ)
+	if err != nil {
+		return nil, fmt.Errorf("error getting incomplete %s: %v", e.name, err)
+	}
+
+	te := &TypeEstimate{Type: e.typ, Remote: remote, Local: local, Incomplete: len(docs), Calls: make(map[string]int)}
+
+	behind := 0
+	if remote > local {
+		behind = remote - local
+	}
+
+	if run[PhaseBasic] {
+		// the first page not saved tells the remote count, even if nothing is missing
+		calls := 1
+		if remote != local {
+			cp, err := p.checkpoint(ctx, e, local)
+			if err != nil {
+				return nil, fmt.Errorf("error getting %s checkpoint: %v", e.name, err)
+			}
+
+			skip := skipSaved(savedOffsets(cp), p.limit, remote)
+
+			unsaved := 0
+			for offset := 0; offset < remote; offset += p.limit {
+				if !skip(offset) {
+					unsaved++
+				}
+			}
+
+			if unsaved > calls {
+				calls = unsaved
+			}
+		}
+
+		te.Calls[PhaseBasic] = calls
+	}
+
+	if run[PhaseEnumerate] {
+		// three counts to start with, then every window fetched is split from another at the cost of a count,
+		// and entities missing are fetched by id
+		te.Calls[PhaseEnumerate] = 3 + 2*p.pages(remote) + behind
+	}
+
+	if run[PhaseInfer] {
+		// related collections are inverted from store only
+		te.Calls[PhaseInfer] = 0
+	}
+
+	if run[PhaseComplement] {
+		calls := 0
+		for _, doc := range docs {
+			calls += 1 + p.relatedCalls(doc)
+		}
+
+		// entities loaded by earlier phases of the run are fetched by id at least
+		if run[PhaseBasic] || run[PhaseEnumerate] {
+			calls += behind
+		}
+
+		te.Calls[PhaseComplement] = calls
+	}
+
+	if run[PhaseVerify] {
+		te.Calls[PhaseVerify] = 1
+	}
+
+	return te, nil
+}
+
+// relatedCalls returns api calls to fetch ids missing in lists of related entities of doc.
+// Lists of documents without available counts are taken as complete unless as long as returned at most.
+func (p *Processor) relatedCalls(doc maco.Doc) int {
+	available, lists := relatedOf(doc)
+
+	var calls int
+	for typ, ids := range lists {
+		n, ok := available[typ]
+		switch {
+		case ok && n != len(*ids):
+			calls += p.pages(n)
+		case !ok && len(*ids) >= maxReturned:
+			calls++
+		}
+	}
+
+	return calls
+}
+
+// pages returns how many pages n entities take, at least one.
+func (p *Processor) pages(n int) int {
+	if n <= p.limit {
+		return 1
+	}
+
+	return (n + p.limit - 1) / p.limit
+}
+
+// relatedOf returns available counts and lists of related ids of doc, keyed by type.
+func relatedOf(doc maco.Doc) (map[string]int, map[string]*[]int) {
+	switch v := doc.(type) {
+	case *maco.Character:
+		return v.Available, map[string]*[]int{
+			maco.TypeComics:  &v.Comics,
+			maco.TypeEvents:  &v.Events,
+			maco.TypeSeries:  &v.Series,
+			maco.TypeStories: &v.Stories,
+		}
+	case *maco.Comic:
+		return v.Available, map[string]*[]int{
+			maco.TypeCharacters: &v.Characters,
+			maco.TypeCreators:   &v.Creators,
+			maco.TypeEvents:     &v.Events,
+			maco.TypeStories:    &v.Stories,
+		}
+	case *maco.Creator:
+		return v.Available, map[string]*[]int{
+			maco.TypeComics:  &v.Comics,
+			maco.TypeEvents:  &v.Events,
+			maco.TypeSeries:  &v.Series,
+			maco.TypeStories: &v.Stories,
+		}
+	case *maco.Event:
+		return v.Available, map[string]*[]int{
+			maco.TypeCharacters: &v.Characters,
+			maco.TypeComics:     &v.Comics,
+			maco.TypeCreators:   &v.Creators,
+			maco.TypeSeries:     &v.Series,
+			maco.TypeStories:    &v.Stories,
+		}
+	case *maco.Series:
+		return v.Available, map[string]*[]int{
+			maco.TypeCharacters: &v.Characters,
+			maco.TypeComics:     &v.Comics,
+			maco.TypeCreators:   &v.Creators,
+			maco.TypeEvents:     &v.Events,
+			maco.TypeStories:    &v.Stories,
+		}
+	case *maco.Story:
+		return v.Available, map[string]*[]int{
+			maco.TypeCharacters: &v.Characters,
+			maco.TypeComics:     &v.Comics,
+			maco.TypeCreators:   &v.Creators,
+			maco.TypeEvents:     &v.Events,
+			maco.TypeSeries:     &v.Series,
+		}
+	}
+
+	return nil, nil
+}
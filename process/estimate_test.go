@@ -0,0 +1,62 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/loivis/marvel-comics-api-data-loader/client/marvel"
+	"github.com/loivis/marvel-comics-api-data-loader/maco"
+)
+
+func TestProcessor_DryRun(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"offset":0,"limit":1,"total":250,"count":1,"results":[]}}`)
+	}))
+	defer ts.Close()
+
+	store := &memStore{docs: map[string][]maco.Doc{maco.TypeCharacters: {
+		&maco.Character{ID: 1, Available: map[string]int{maco.TypeComics: 45}, Comics: make([]int, 20)},
+		&maco.Character{ID: 2, Available: map[string]int{maco.TypeComics: 250}, Comics: make([]int, 20)},
+		&maco.Character{ID: 3, Comics: make([]int, 20)},
+		&maco.Character{ID: 4, Intact: true},
+	}}}
+
+	p := NewProcessor(marvel.NewClient(ts.URL, "", "public"), store, "", "",
+		WithTypes(maco.TypeCharacters),
+		WithPhases(phases...),
+	)
+
+	est, err := p.DryRun(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if got, want := len(est.Types), 1; got != want {
+		t.Fatalf("got %d types, want %d", got, want)
+	}
+
+	te := est.Types[0]
+
+	if got, want := [3]int{te.Remote, te.Local, te.Incomplete}, [3]int{250, 4, 3}; got != want {
+		t.Errorf("got remote, local and incomplete %v, want %v", got, want)
+	}
+
+	want := map[string]int{
+		PhaseBasic:      3,
+		PhaseEnumerate:  3 + 2*3 + 246,
+		PhaseInfer:      0,
+		PhaseComplement: 2 + 4 + 2 + 246,
+		PhaseVerify:     1,
+	}
+	if got := te.Calls; !reflect.DeepEqual(got, want) {
+		t.Errorf("got calls %v, want %v", got, want)
+	}
+
+	if store.checkpoint != nil || store.tombstones != nil {
+		t.Errorf("store written in dry run")
+	}
+}
@@ -0,0 +1,156 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/loivis/marvel-comics-api-data-loader/maco"
+)
+
+/*
+	relations go both ways, comics of a character are those listing the character in their characters.
+	once a collection is complete, i.e. all stored documents of it intact,
+	lists of ids of other entities related to it are inverted from it instead of fetched from the api.
+	the total of the api is no measure of complete, as it counts duplicates of e.g. comics.
+	an entity is only marked intact when every list is as long as available,
+	so entities missing from a related collection leave lists too short and to complement as they are.
+*/
+
+// infer fills lists of related ids of incomplete entities of e from collections already complete,
+// and saves those with all lists as long as available as intact.
+func (p *Processor) infer(ctx context.Context, e *entity) error {
+	docs, err := p.store.IncompleteDocs(ctx, e.typ)
+	if err != nil {
+		return fmt.Errorf("error getting incomplete %s: %v", e.name, err)
+	}
+
+	if len(docs) == 0 {
+		log.Info().Msgf("no incomplete %s to infer", e.name)
+		return nil
+	}
+
+	// inverted lists of complete collections, keyed by type and then id of e
+	inverted := make(map[string]map[int][]int)
+
+	_, lists := relatedOf(docs[0])
+	for typ := range lists {
+		inv, err := p.invert(ctx, typ, e.typ)
+		if err != nil {
+			return err
+		}
+
+		if inv != nil {
+			inverted[typ] = inv
+		}
+	}
+
+	if len(inverted) == 0 {
+		log.Info().Msgf("no complete collection related to %s", e.name)
+		return nil
+	}
+
+	var inferred int
+
+	for _, doc := range docs {
+		if !inferFrom(inverted, doc) {
+			continue
+		}
+
+		markIntact(doc)
+
+		if err := p.store.SaveOne(ctx, doc); err != nil {
+			return fmt.Errorf("error saving %s %d: %v", e.name, doc.Identify(), err)
+		}
+
+		inferred++
+	}
+
+	log.Info().Int("count", inferred).Int("incomplete", len(docs)).Msgf("inferred %s from related collections", e.typ)
+
+	return nil
+}
+
+// inferFrom fills lists of doc from inverted lists, and returns whether all of them are now as long as available.
+// Lists are left as they are unless all of them can be filled.
+func inferFrom(inverted map[string]map[int][]int, doc maco.Doc) bool {
+	available, lists := relatedOf(doc)
+	if available == nil {
+		return false
+	}
+
+	filled := make(map[string][]int)
+
+	for typ, ids := range lists {
+		n := available[typ]
+		if len(*ids) == n {
+			continue
+		}
+
+		inv, ok := inverted[typ]
+		if !ok || len(inv[doc.Identify()]) != n {
+			return false
+		}
+
+		filled[typ] = inv[doc.Identify()]
+	}
+
+	for typ, ids := range filled {
+		*lists[typ] = ids
+	}
+
+	return true
+}
+
+// invert returns lists of ids of typ keyed by ids of related in their list of related,
+// e.g. characters of each comic from comics of each character, or nil if typ is not complete.
+func (p *Processor) invert(ctx context.Context, typ, related string) (map[int][]int, error) {
+	e, err := entityOf(typ)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := p.store.GetCount(ctx, typ)
+	if err != nil {
+		return nil, fmt.Errorf("error getting local %s count: %v", e.name, err)
+	}
+
+	incomplete, err := p.store.IncompleteIDs(ctx, typ)
+	if err != nil {
+		return nil, fmt.Errorf("error getting incomplete %s ids: %v", e.name, err)
+	}
+
+	if count == 0 || len(incomplete) > 0 {
+		log.Info().Str("type", typ).Int("count", count).Int("incomplete", len(incomplete)).Msgf("%s not complete to infer %s from", typ, related)
+		return nil, nil
+	}
+
+	docs, err := p.store.GetDocs(ctx, typ, related)
+	if err != nil {
+		return nil, fmt.Errorf("error getting %s of %s: %v", related, typ, err)
+	}
+
+	inv := make(map[int][]int)
+
+	for _, doc := range docs {
+		_, lists := relatedOf(doc)
+
+		ids, ok := lists[related]
+		if !ok {
+			// e.g. series of comics, which hold only the id of their series
+			return nil, nil
+		}
+
+		for _, id := range *ids {
+			inv[id] = append(inv[id], doc.Identify())
+		}
+	}
+
+	for _, ids := range inv {
+		sort.Ints(ids)
+	}
+
+	return inv, nil
+}
@@ -0,0 +1,82 @@
+package process
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/loivis/marvel-comics-api-data-loader/client/marvel"
+	"github.com/loivis/marvel-comics-api-data-loader/maco"
+)
+
+func TestProcessor_Infer(t *testing.T) {
+	available := func(characters, creators int) map[string]int {
+		return map[string]int{maco.TypeCharacters: characters, maco.TypeCreators: creators, maco.TypeEvents: 0, maco.TypeStories: 0}
+	}
+
+	for _, tc := range []struct {
+		desc           string
+		characters     []maco.Doc
+		wantSaved      []int
+		wantCharacters map[int][]int // characters of each comic after inference
+	}{
+		{
+			desc: "Complete",
+			characters: []maco.Doc{
+				&maco.Character{ID: 1, Intact: true, Comics: []int{10, 11}},
+				&maco.Character{ID: 2, Intact: true, Comics: []int{10}},
+			},
+			wantSaved:      []int{10},
+			wantCharacters: map[int][]int{10: {1, 2}, 11: nil, 12: {1}},
+		},
+		{
+			desc: "Incomplete",
+			characters: []maco.Doc{
+				&maco.Character{ID: 1, Intact: true, Comics: []int{10, 11}},
+				&maco.Character{ID: 2, Comics: []int{10}},
+			},
+			wantSaved:      nil,
+			wantCharacters: map[int][]int{10: {1}, 11: nil, 12: {1}},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			store := &memStore{docs: map[string][]maco.Doc{
+				maco.TypeCharacters: tc.characters,
+				maco.TypeComics: {
+					&maco.Comic{ID: 10, Available: available(2, 0), Characters: []int{1}},
+					&maco.Comic{ID: 11, Available: available(1, 1)},
+					&maco.Comic{ID: 12, Characters: []int{1}},
+				},
+			}}
+
+			// related collections are judged complete from store, without any call to the api
+			p := NewProcessor(marvel.NewClient("http://127.0.0.1:0", "", "public"), store, "", "")
+
+			err := p.infer(context.Background(), comicEntity)
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			if got, want := store.saved, tc.wantSaved; !reflect.DeepEqual(got, want) {
+				t.Errorf("got saved %v, want %v", got, want)
+			}
+
+			saved := make(map[int]bool)
+			for _, id := range tc.wantSaved {
+				saved[id] = true
+			}
+
+			for id, wantCharacters := range tc.wantCharacters {
+				comic := store.get(maco.TypeComics, id).(*maco.Comic)
+
+				if got, want := comic.Intact, saved[id]; got != want {
+					t.Errorf("got comic %d intact %v, want %v", id, got, want)
+				}
+
+				if got, want := comic.Characters, wantCharacters; !reflect.DeepEqual(got, want) {
+					t.Errorf("got comic %d characters %v, want %v", id, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/loivis/marvel-comics-api-data-loader/maco"
+)
+
+// Dangling is a field of stored documents referring to entities missing in store, e.g. characters of comics.
+type Dangling struct {
+	Type   string // type of documents referring, e.g. comics
+	Field  string // field holding references, e.g. characters or series_id
+	Target string // type referred to
+	Refs   int    // references to missing entities
+	IDs    []int  // missing entities in ascending order
+}
+
+func (d *Dangling) String() string {
+	return fmt.Sprintf("%s.%s -> %s: %d references to %d missing", d.Type, d.Field, d.Target, d.Refs, len(d.IDs))
+}
+
+// Integrity lists dangling references of stored documents.
+type Integrity struct {
+	Dangling []*Dangling
+}
+
+func (in *Integrity) String() string {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "dangling references: %d fields\n", len(in.Dangling))
+	for _, d := range in.Dangling {
+		fmt.Fprintf(&buf, "  %s\n", d)
+	}
+
+	return buf.String()
+}
+
+// missing returns ids of all missing entities keyed by type.
+func (in *Integrity) missing() map[string][]int {
+	seen := make(map[string]map[int]bool)
+	for _, d := range in.Dangling {
+		if seen[d.Target] == nil {
+			seen[d.Target] = make(map[int]bool)
+		}
+
+		for _, id := range d.IDs {
+			seen[d.Target][id] = true
+		}
+	}
+
+	missing := make(map[string][]int)
+	for typ, ids := range seen {
+		for id := range ids {
+			missing[typ] = append(missing[typ], id)
+		}
+		sort.Ints(missing[typ])
+	}
+
+	return missing
+}
+
+// reference is a field of a document holding ids of entities of target.
+type reference struct {
+	field  string
+	target string
+	ids    []int
+}
+
+// emptyDocs return an empty document of each type, telling fields it refers to others in.
+var emptyDocs = map[string]func() maco.Doc{
+	maco.TypeCharacters: func() maco.Doc { return &maco.Character{} },
+	maco.TypeComics:     func() maco.Doc { return &maco.Comic{} },
+	maco.TypeCreators:   func() maco.Doc { return &maco.Creator{} },
+	maco.TypeEvents:     func() maco.Doc { return &maco.Event{} },
+	maco.TypeSeries:     func() maco.Doc { return &maco.Series{} },
+	maco.TypeStories:    func() maco.Doc { return &maco.Story{} },
+}
+
+// referencesOf returns references of doc to other entities, in order of field.
+func referencesOf(doc maco.Doc) []*reference {
+	var refs []*reference
+
+	_, lists := relatedOf(doc)
+	for typ, ids := range lists {
+		refs = append(refs, &reference{field: typ, target: typ, ids: *ids})
+	}
+
+	// ids of single entities are 0 if none
+	one := func(id int) []int {
+		if id == 0 {
+			return nil
+		}
+		return []int{id}
+	}
+
+	switch v := doc.(type) {
+	case *maco.Comic:
+		refs = append(refs,
+			&reference{field: "collected_issues", target: maco.TypeComics, ids: v.CollectedIssues},
+			&reference{field: "collections", target: maco.TypeComics, ids: v.Collections},
+			&reference{field: "series_id", target: maco.TypeSeries, ids: one(v.SeriesID)},
+			&reference{field: "variants", target: maco.TypeComics, ids: v.Variants},
+		)
+	case *maco.Event:
+		refs = append(refs,
+			&reference{field: "next", target: maco.TypeEvents, ids: one(v.Next)},
+			&reference{field: "previous", target: maco.TypeEvents, ids: one(v.Previous)},
+		)
+	case *maco.Series:
+		refs = append(refs,
+			&reference{field: "next", target: maco.TypeSeries, ids: one(v.Next)},
+			&reference{field: "previous", target: maco.TypeSeries, ids: one(v.Previous)},
+		)
+	case *maco.Story:
+		refs = append(refs, &reference{field: "originalissue", target: maco.TypeComics, ids: one(v.OriginalIssue)})
+	}
+
+	sort.Slice(refs, func(i, j int) bool { return refs[i].field < refs[j].field })
+
+	return refs
+}
+
+// CheckReferences scans stored documents of selected types for references to entities missing in store.
+// Entities of any type may be referred to, and tombstoned ones count as stored.
+func (p *Processor) CheckReferences(ctx context.Context) (*Integrity, error) {
+	types, _, err := p.plan()
+	if err != nil {
+		return nil, err
+	}
+
+	stored := make(map[string]map[int]bool)
+	exists := func(typ string) (map[int]bool, error) {
+		if ids, ok := stored[typ]; ok {
+			return ids, nil
+		}
+
+		ids, err := p.store.GetIDs(ctx, typ)
+		if err != nil {
+			return nil, fmt.Errorf("error getting local %s ids: %v", typ, err)
+		}
+
+		stored[typ] = make(map[int]bool, len(ids))
+		for _, id := range ids {
+			stored[typ][id] = true
+		}
+
+		return stored[typ], nil
+	}
+
+	in := &Integrity{}
+
+	for _, typ := range types {
+		var fields []string
+		for _, ref := range referencesOf(emptyDocs[typ]()) {
+			fields = append(fields, ref.field)
+		}
+
+		docs, err := p.store.GetDocs(ctx, typ, fields...)
+		if err != nil {
+			return nil, fmt.Errorf("error getting %s: %v", typ, err)
+		}
+
+		dangling := make(map[string]*Dangling)
+		missing := make(map[string]map[int]bool)
+
+		for _, doc := range docs {
+			for _, ref := range referencesOf(doc) {
+				ids, err := exists(ref.target)
+				if err != nil {
+					return nil, err
+				}
+
+				for _, id := range ref.ids {
+					if ids[id] {
+						continue
+					}
+
+					if dangling[ref.field] == nil {
+						dangling[ref.field] = &Dangling{Type: typ, Field: ref.field, Target: ref.target}
+						missing[ref.field] = make(map[int]bool)
+					}
+
+					dangling[ref.field].Refs++
+					missing[ref.field][id] = true
+				}
+			}
+		}
+
+		for _, field := range fields {
+			d, ok := dangling[field]
+			if !ok {
+				continue
+			}
+
+			for id := range missing[field] {
+				d.IDs = append(d.IDs, id)
+			}
+			sort.Ints(d.IDs)
+
+			log.Warn().Str("type", typ).Str("field", field).Int("refs", d.Refs).Int("missing", len(d.IDs)).Msgf("dangling references to %s", d.Target)
+
+			in.Dangling = append(in.Dangling, d)
+		}
+
+		log.Info().Str("type", typ).Int("count", len(docs)).Msgf("checked references of %s", typ)
+	}
+
+	return in, nil
+}
+
+// RepairReferences fetches entities missing for dangling references in with full info and inserts them.
+// Entities not found are tombstoned.
+func (p *Processor) RepairReferences(ctx context.Context, in *Integrity) error {
+	missing := in.missing()
+
+	for _, typ := range types {
+		ids := missing[typ]
+		if len(ids) == 0 {
+			continue
+		}
+
+		e, err := entityOf(typ)
+		if err != nil {
+			return err
+		}
+
+		log.Info().Str("type", typ).Int("count", len(ids)).Msgf("repairing references to %s", typ)
+
+		if err := p.complementIDs(ctx, e, ids); err != nil {
+			return p.checkQuota(fmt.Errorf("error repairing references to %s: %v", typ, err))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package process
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/loivis/marvel-comics-api-data-loader/maco"
+)
+
+func TestProcessor_CheckReferences(t *testing.T) {
+	// character 2 and series 5 found by id, nothing else
+	s := characterServer(t, []int{2})
+	defer s.Close()
+
+	if err := s.Load(maco.TypeSeries, []byte(`[{"id":5,"thumbnail":{},"characters":{},"comics":{},"creators":{},"events":{},"stories":{}}]`)); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	store := &memStore{docs: map[string][]maco.Doc{
+		maco.TypeCharacters: {
+			&maco.Character{ID: 1, Comics: []int{10, 99}},
+		},
+		maco.TypeComics: {
+			&maco.Comic{ID: 10, Characters: []int{1, 2}, SeriesID: 5, Variants: []int{10}},
+		},
+	}}
+
+	p := NewProcessor(clientOf(s), store, "", "", WithTypes(maco.TypeCharacters, maco.TypeComics))
+
+	in, err := p.CheckReferences(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	want := []*Dangling{
+		{Type: maco.TypeCharacters, Field: "comics", Target: maco.TypeComics, Refs: 1, IDs: []int{99}},
+		{Type: maco.TypeComics, Field: "characters", Target: maco.TypeCharacters, Refs: 1, IDs: []int{2}},
+		{Type: maco.TypeComics, Field: "series_id", Target: maco.TypeSeries, Refs: 1, IDs: []int{5}},
+	}
+	if got := in.Dangling; !reflect.DeepEqual(got, want) {
+		t.Errorf("got dangling %v, want %v", got, want)
+	}
+
+	err = p.RepairReferences(context.Background(), in)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	in, err = p.CheckReferences(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if got, want := in.Dangling, want[:1]; !reflect.DeepEqual(got, want) {
+		t.Errorf("got dangling %v after repair, want %v", got, want)
+	}
+
+	if got, want := len(p.report.Removals), 1; got != want {
+		t.Errorf("got %d removals, want %d", got, want)
+	}
+}
@@ -0,0 +1,89 @@
+package process
+
+import (
+	"fmt"
+)
+
+// Option configures a Processor.
+type Option func(*Processor)
+
+// Phases of loading each type, in the order they run.
+const (
+	PhaseBasic      = "basic"      // page over all entities with basic info
+	PhaseEnumerate  = "enumerate"  // enumerate all ids in windows of modified time and load those missing
+	PhaseInfer      = "infer"      // fill lists of related ids of incomplete entities from complete collections
+	PhaseComplement = "complement" // fetch sub-resources of incomplete entities
+	PhaseVerify     = "verify"     // compare stored documents with counts from api
+)
+
+// phases lists all phases in the order they run.
+var phases = []string{PhaseBasic, PhaseEnumerate, PhaseInfer, PhaseComplement, PhaseVerify}
+
+// WithConcurrency sets how many entities, or pages of one listing, are fetched concurrently, 10 by default.
+// Requests in flight are capped by the scheduler of the client, if any, rather than by n.
+func WithConcurrency(n int) Option {
+	return func(p *Processor) {
+		if n > 0 {
+			p.concurrency = n
+		}
+	}
+}
+
+// WithTypes limits a run to the given types, e.g. maco.TypeEvents.
+// Types still run in the order of dependencies whatever order they are given in.
+// All types run by default.
+func WithTypes(types ...string) Option {
+	return func(p *Processor) {
+		p.types = types
+	}
+}
+
+// WithPhases limits a run to the given phases, e.g. PhaseComplement, of each type.
+// PhaseBasic, PhaseInfer and PhaseComplement run by default.
+func WithPhases(phases ...string) Option {
+	return func(p *Processor) {
+		p.phases = phases
+	}
+}
+
+// plan returns selected types in the order they run, and whether each phase is selected.
+func (p *Processor) plan() ([]string, map[string]bool, error) {
+	selected := make(map[string]bool)
+	for _, typ := range p.types {
+		if _, err := entityOf(typ); err != nil {
+			return nil, nil, err
+		}
+		selected[typ] = true
+	}
+
+	var ordered []string
+	for _, typ := range types {
+		if len(selected) == 0 || selected[typ] {
+			ordered = append(ordered, typ)
+		}
+	}
+
+	run := map[string]bool{PhaseBasic: true, PhaseInfer: true, PhaseComplement: true}
+	if len(p.phases) > 0 {
+		run = make(map[string]bool)
+	}
+
+	for _, phase := range p.phases {
+		if !contains(phases, phase) {
+			return nil, nil, fmt.Errorf("unsupported phase: %s", phase)
+		}
+		run[phase] = true
+	}
+
+	return ordered, run, nil
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
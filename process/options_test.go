@@ -0,0 +1,68 @@
+package process
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/loivis/marvel-comics-api-data-loader/maco"
+)
+
+func TestProcessor_Plan(t *testing.T) {
+	for _, tc := range []struct {
+		desc       string
+		opts       []Option
+		wantTypes  []string
+		wantPhases map[string]bool
+	}{
+		{
+			desc:       "Default",
+			wantTypes:  types,
+			wantPhases: map[string]bool{PhaseBasic: true, PhaseInfer: true, PhaseComplement: true},
+		},
+		{
+			desc:       "Ordered",
+			opts:       []Option{WithTypes(maco.TypeStories, maco.TypeEvents)},
+			wantTypes:  []string{maco.TypeEvents, maco.TypeStories},
+			wantPhases: map[string]bool{PhaseBasic: true, PhaseInfer: true, PhaseComplement: true},
+		},
+		{
+			desc:       "Phases",
+			opts:       []Option{WithTypes(maco.TypeEvents), WithPhases(PhaseVerify, PhaseComplement)},
+			wantTypes:  []string{maco.TypeEvents},
+			wantPhases: map[string]bool{PhaseComplement: true, PhaseVerify: true},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			p := NewProcessor(nil, nil, "", "", tc.opts...)
+
+			types, phases, err := p.plan()
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			if got, want := types, tc.wantTypes; !reflect.DeepEqual(got, want) {
+				t.Errorf("got types %v, want %v", got, want)
+			}
+
+			if got, want := phases, tc.wantPhases; !reflect.DeepEqual(got, want) {
+				t.Errorf("got phases %v, want %v", got, want)
+			}
+		})
+	}
+
+	t.Run("UnsupportedType", func(t *testing.T) {
+		_, _, err := NewProcessor(nil, nil, "", "", WithTypes("foo")).plan()
+
+		if err == nil {
+			t.Fatal("error is nil")
+		}
+	})
+
+	t.Run("UnsupportedPhase", func(t *testing.T) {
+		_, _, err := NewProcessor(nil, nil, "", "", WithPhases("foo")).plan()
+
+		if err == nil {
+			t.Fatal("error is nil")
+		}
+	})
+}
@@ -2,6 +2,8 @@ package process
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net"
 	"strconv"
 	"strings"
@@ -24,10 +26,15 @@ type Processor struct {
 	storeBatch int
 
 	concurrency int
+
+	types  []string // types to run, all if empty
+	phases []string // phases to run of each type, basic, infer and complement if empty
+
+	report *Report
 }
 
-func NewProcessor(mc *marvel.Client, s maco.Store, private, public string) *Processor {
-	return &Processor{
+func NewProcessor(mc *marvel.Client, s maco.Store, private, public string, opts ...Option) *Processor {
+	p := &Processor{
 		mclient:    mc,
 		privateKey: private,
 		publicKey:  public,
@@ -38,47 +45,125 @@ func NewProcessor(mc *marvel.Client, s maco.Store, private, public string) *Proc
 		storeBatch: 1000,
 
 		concurrency: 10,
+
+		report: NewReport(),
 	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
 }
 
-func (p *Processor) Process(ctx context.Context) error {
-	var err error
+// Report returns anomalies of api data found so far.
+func (p *Processor) Report() *Report {
+	return p.report
+}
 
-	err = p.loadCharacters(ctx)
-	if err != nil {
-		return err
-	}
+// ErrQuotaExhausted is returned when a run stops because the daily api quota is used up.
+// Running again on the next utc day resumes the load.
+var ErrQuotaExhausted = errors.New("daily api quota exhausted")
+
+// types lists collections in the order they are loaded.
+var types = []string{
+	maco.TypeCharacters,
+	maco.TypeComics,
+	maco.TypeCreators,
+	maco.TypeEvents,
+	maco.TypeSeries,
+	maco.TypeStories,
+}
 
-	err = p.loadComics(ctx)
+func (p *Processor) Process(ctx context.Context) error {
+	types, run, err := p.plan()
 	if err != nil {
 		return err
 	}
 
-	err = p.loadCreators(ctx)
-	if err != nil {
-		return err
-	}
+	started := time.Now()
 
-	err = p.loadEvents(ctx)
-	if err != nil {
-		return err
+	var unverified []string
+
+	for _, typ := range types {
+		e, err := entityOf(typ)
+		if err != nil {
+			return err
+		}
+
+		if run[PhaseBasic] {
+			err = p.loadAllWithBasicInfo(ctx, e)
+			if err != nil {
+				return p.checkQuota(fmt.Errorf("error loading all %s info: %v", typ, err))
+			}
+
+			log.Info().Msgf("all %s loaded", typ)
+		}
+
+		if run[PhaseEnumerate] {
+			err = p.loadUnlisted(ctx, e)
+			if err != nil {
+				return p.checkQuota(fmt.Errorf("error enumerating %s: %v", typ, err))
+			}
+		}
+
+		if run[PhaseInfer] {
+			err = p.infer(ctx, e)
+			if err != nil {
+				return p.checkQuota(fmt.Errorf("error inferring %s: %v", typ, err))
+			}
+		}
+
+		if run[PhaseComplement] {
+			err = p.complementAll(ctx, e)
+			if err != nil {
+				return p.checkQuota(fmt.Errorf("error complementing all %s: %v", typ, err))
+			}
+
+			log.Info().Msgf("all %s complemented", typ)
+		}
+
+		if run[PhaseBasic] {
+			err = p.markIfMissing(ctx, typ, started)
+			if err != nil {
+				return fmt.Errorf("error marking %s synced: %v", typ, err)
+			}
+		}
+
+		if run[PhaseVerify] {
+			ok, err := p.verify(ctx, e)
+			if err != nil {
+				return p.checkQuota(fmt.Errorf("error verifying %s: %v", typ, err))
+			}
+
+			if !ok {
+				unverified = append(unverified, typ)
+			}
+		}
 	}
 
-	err = p.loadSeries(ctx)
-	if err != nil {
-		return err
+	if len(unverified) > 0 {
+		return fmt.Errorf("verification failed for %s", strings.Join(unverified, ", "))
 	}
 
-	err = p.loadStories(ctx)
-	if err != nil {
+	return nil
+}
+
+// checkQuota returns ErrQuotaExhausted instead of err if the api quota is used up,
+// so that callers can stop cleanly instead of treating it as failure.
+func (p *Processor) checkQuota(err error) error {
+	remaining, ok := p.mclient.Remaining()
+	if !ok || remaining > 0 {
 		return err
 	}
 
-	return nil
+	log.Warn().Msgf("stopped as api quota exhausted: %v", err)
+
+	return ErrQuotaExhausted
 }
 
-func idFromURL(in string) (int, error) {
-	ss := strings.Split(strings.Trim(in, "/"), "/")
+func idFromURL(in marvel.String) (int, error) {
+	ss := strings.Split(strings.Trim(string(in), "/"), "/")
 	s := ss[len(ss)-1]
 	id, err := strconv.Atoi(s)
 	if err != nil {
@@ -88,11 +173,40 @@ func idFromURL(in string) (int, error) {
 	return id, nil
 }
 
+// resourceID is like idFromURL, but also reports resource uris without an id.
+func (p *Processor) resourceID(uri marvel.String) (int, error) {
+	id, err := idFromURL(uri)
+	if err != nil {
+		p.report.invalidURI(string(uri))
+	}
+
+	return id, err
+}
+
+// pager returns a marvel.Pager starting at offset with limit, concurrency, retries and timeout of the processor.
+func (p *Processor) pager(offset int) *marvel.Pager {
+	return &marvel.Pager{
+		Attempts:    10,
+		Concurrency: p.concurrency,
+		Limit:       p.limit,
+		Offset:      offset,
+		OnRetry:     func(offset int, n uint, err error) { retryLog(offset)(n, err) },
+		RetryIf:     func(offset int, err error) bool { return retryIf(offset)(err) },
+		Timeout:     p.timeout,
+	}
+}
+
 func retryIf(offset int) func(error) bool {
 	return func(err error) bool {
-		if v, ok := err.(*marvel.APIError); ok && v.Code != 429 {
-			log.Error().Int("offset", offset).Int("code", v.Code).Msgf("retryable api error: %v", err)
-			return true
+		var r interface{ Retryable() bool }
+		if errors.As(err, &r) {
+			if r.Retryable() {
+				log.Error().Int("offset", offset).Msgf("retryable %[1]T error: %[1]v", err)
+				return true
+			}
+
+			log.Error().Int("offset", offset).Msgf("non-retryable %[1]T error: %[1]v", err)
+			return false
 		}
 
 		if err, ok := err.(net.Error); ok && err.Timeout() {
@@ -1,7 +1,14 @@
 package process
 
 import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
+
+	"github.com/loivis/marvel-comics-api-data-loader/maco"
 )
 
 func Test_IDFromURL(t *testing.T) {
@@ -35,3 +42,61 @@ func Test_IDFromURL(t *testing.T) {
 		}
 	})
 }
+
+func TestProcessor_Process(t *testing.T) {
+	// characters 1 to 5, the first in 25 comics of which only 20 are listed in its summary
+	s := characterServer(t, []int{1, 2, 3, 4, 5})
+	defer s.Close()
+
+	var items, comics []string
+	for id := 101; id <= 125; id++ {
+		uri := fmt.Sprintf(`{"resourceURI":"http://gateway.marvel.com/v1/public/comics/%d"}`, id)
+		if len(items) < 20 {
+			items = append(items, uri)
+		}
+		comics = append(comics, fmt.Sprintf(`{"id":%d,"characters":{"items":[{"resourceURI":"http://gateway.marvel.com/v1/public/characters/1"}]}}`, id))
+	}
+
+	if err := s.Load(maco.TypeCharacters, []byte(fmt.Sprintf(`[{"id":1,"thumbnail":{},"comics":{"available":25,"returned":20,"items":[%s]},"events":{},"series":{},"stories":{}}]`, strings.Join(items, ",")))); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if err := s.Load(maco.TypeComics, []byte("["+strings.Join(comics, ",")+"]")); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	store := &memStore{docs: make(map[string][]maco.Doc)}
+
+	p := NewProcessor(clientOf(s), store, "", "",
+		WithTypes(maco.TypeCharacters),
+		WithPhases(PhaseBasic, PhaseInfer, PhaseComplement, PhaseVerify),
+	)
+	p.limit = 2
+
+	if err := p.Process(context.Background()); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	ids, _ := store.GetIDs(context.Background(), maco.TypeCharacters)
+	if got, want := len(ids), 5; got != want {
+		t.Fatalf("got %d characters, want %d", got, want)
+	}
+
+	var want []int
+	for id := 101; id <= 125; id++ {
+		want = append(want, id)
+	}
+
+	char := store.get(maco.TypeCharacters, 1).(*maco.Character)
+
+	// pages of comics are fetched concurrently
+	sort.Ints(char.Comics)
+
+	if got := char.Comics; !reflect.DeepEqual(got, want) {
+		t.Errorf("got comics %v, want %v", got, want)
+	}
+
+	if !char.Intact {
+		t.Error("got character not intact")
+	}
+}
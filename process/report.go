@@ -0,0 +1,287 @@
+package process
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/loivis/marvel-comics-api-data-loader/client/marvel"
+)
+
+// Report collects anomalies of api data found during one run.
+type Report struct {
+	Started time.Time `json:"started"`
+
+	// Coercions counts values not matching the type of their field, keyed like "number to string".
+	Coercions map[string]int `json:"coercions"`
+	// CountMismatches lists sub-lists where available of the entity differs from total of the sub-resource.
+	CountMismatches []*CountMismatch `json:"count_mismatches"`
+	// DuplicateComics lists ids repeated across pages of /v1/public/comics.
+	DuplicateComics []int `json:"duplicate_comics"`
+	// Enumerations lists collections enumerated in windows of modified time, with windows coming up short.
+	Enumerations []*Enumeration `json:"enumerations,omitempty"`
+	// InvalidURIs lists resource uris without an id.
+	InvalidURIs []string `json:"invalid_uris"`
+	// Removals lists entities found removed upstream and tombstoned.
+	Removals []*Removal `json:"removals,omitempty"`
+
+	mu sync.Mutex
+}
+
+// CountMismatch is a sub-list of an entity, e.g. comics of character 1009610, with a count not as available.
+type CountMismatch struct {
+	Type      string `json:"type"`
+	ID        int    `json:"id"`
+	List      string `json:"list"`
+	Available int    `json:"available"`
+	Total     int    `json:"total"`
+}
+
+func (m *CountMismatch) String() string {
+	return fmt.Sprintf("%s %d %s: available %d, total %d", m.Type, m.ID, m.List, m.Available, m.Total)
+}
+
+// Removal is an entity found removed upstream, e.g. unlisted character 1009610.
+type Removal struct {
+	Type   string `json:"type"`
+	ID     int    `json:"id"`
+	Reason string `json:"reason"`
+}
+
+func (r *Removal) String() string {
+	return fmt.Sprintf("%s %d: %s", r.Type, r.ID, r.Reason)
+}
+
+// NewReport returns an empty report of a run started now.
+func NewReport() *Report {
+	return &Report{
+		Started:   time.Now().UTC(),
+		Coercions: make(map[string]int),
+	}
+}
+
+func (r *Report) countMismatch(typ string, id int, list string, available, total int) {
+	if available == total {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.CountMismatches = append(r.CountMismatches, &CountMismatch{Type: typ, ID: id, List: list, Available: available, Total: total})
+}
+
+func (r *Report) duplicateComic(id int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.DuplicateComics = append(r.DuplicateComics, id)
+}
+
+func (r *Report) enumeration(en *Enumeration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Enumerations = append(r.Enumerations, en)
+}
+
+func (r *Report) removal(typ string, id int, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Removals = append(r.Removals, &Removal{Type: typ, ID: id, Reason: reason})
+}
+
+func (r *Report) invalidURI(uri string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.InvalidURIs = append(r.InvalidURIs, uri)
+}
+
+// Write saves the report as json into dir along with a summary compared to the previous report in dir,
+// and returns the summary.
+// Files are named after the start of the run, e.g. anomalies-20190501T000000Z.json and anomalies-20190501T000000Z.txt.
+func (r *Report) Write(dir string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for k, v := range marvel.Coercions() {
+		r.Coercions[k] = v
+	}
+
+	sort.Slice(r.CountMismatches, func(i, j int) bool {
+		return r.CountMismatches[i].String() < r.CountMismatches[j].String()
+	})
+	sort.Ints(r.DuplicateComics)
+	sort.Strings(r.InvalidURIs)
+	sort.Slice(r.Removals, func(i, j int) bool {
+		return r.Removals[i].String() < r.Removals[j].String()
+	})
+
+	previous, err := previousReport(dir, r.Started)
+	if err != nil {
+		return "", fmt.Errorf("error reading previous report: %v", err)
+	}
+
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	name := filepath.Join(dir, "anomalies-"+r.Started.Format(reportLayout))
+
+	if err := ioutil.WriteFile(name+".json", b, 0644); err != nil {
+		return "", err
+	}
+
+	summary := r.summary(previous)
+
+	if err := ioutil.WriteFile(name+".txt", []byte(summary), 0644); err != nil {
+		return "", err
+	}
+
+	return summary, nil
+}
+
+const reportLayout = "20060102T150405Z"
+
+// previousReport returns the latest report in dir of a run started before started, or nil if none.
+func previousReport(dir string, started time.Time) (*Report, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "anomalies-*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	// names sort by start of run
+	sort.Strings(names)
+
+	current := filepath.Join(dir, "anomalies-"+started.Format(reportLayout)+".json")
+
+	for i := len(names) - 1; i >= 0; i-- {
+		if names[i] >= current {
+			continue
+		}
+
+		b, err := ioutil.ReadFile(names[i])
+		if err != nil {
+			return nil, err
+		}
+
+		var r Report
+		if err := json.Unmarshal(b, &r); err != nil {
+			return nil, fmt.Errorf("error decoding %q: %v", names[i], err)
+		}
+
+		return &r, nil
+	}
+
+	return nil, nil
+}
+
+// summary describes anomalies of the report, and how they changed since previous if not nil.
+func (r *Report) summary(previous *Report) string {
+	if previous == nil {
+		previous = &Report{}
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "anomalies of run started at %s", r.Started.Format(time.RFC3339))
+	if !previous.Started.IsZero() {
+		fmt.Fprintf(&buf, ", compared to run started at %s", previous.Started.Format(time.RFC3339))
+	}
+	fmt.Fprintln(&buf)
+
+	var coercions []string
+	for k := range r.Coercions {
+		coercions = append(coercions, k)
+	}
+	for k := range previous.Coercions {
+		if _, ok := r.Coercions[k]; !ok {
+			coercions = append(coercions, k)
+		}
+	}
+	sort.Strings(coercions)
+
+	fmt.Fprintf(&buf, "\ntype coercions: %d kinds\n", len(r.Coercions))
+	for _, k := range coercions {
+		fmt.Fprintf(&buf, "  %s: %d (previous %d)\n", k, r.Coercions[k], previous.Coercions[k])
+	}
+
+	var mismatches, previousMismatches []string
+	for _, m := range r.CountMismatches {
+		mismatches = append(mismatches, m.String())
+	}
+	for _, m := range previous.CountMismatches {
+		previousMismatches = append(previousMismatches, m.String())
+	}
+	writeChanges(&buf, "available vs total mismatches", mismatches, previousMismatches)
+
+	var duplicates, previousDuplicates []string
+	for _, id := range r.DuplicateComics {
+		duplicates = append(duplicates, fmt.Sprint(id))
+	}
+	for _, id := range previous.DuplicateComics {
+		previousDuplicates = append(previousDuplicates, fmt.Sprint(id))
+	}
+	writeChanges(&buf, "duplicated comic ids", duplicates, previousDuplicates)
+
+	writeChanges(&buf, "invalid resource uris", r.InvalidURIs, previous.InvalidURIs)
+
+	var windows, previousWindows []string
+	for _, en := range r.Enumerations {
+		for _, w := range en.Short {
+			windows = append(windows, en.Type+" "+w.String())
+		}
+	}
+	for _, en := range previous.Enumerations {
+		for _, w := range en.Short {
+			previousWindows = append(previousWindows, en.Type+" "+w.String())
+		}
+	}
+	writeChanges(&buf, "short windows of enumeration", windows, previousWindows)
+
+	var removals, previousRemovals []string
+	for _, rm := range r.Removals {
+		removals = append(removals, rm.String())
+	}
+	for _, rm := range previous.Removals {
+		previousRemovals = append(previousRemovals, rm.String())
+	}
+	writeChanges(&buf, "entities removed upstream", removals, previousRemovals)
+
+	return buf.String()
+}
+
+// writeChanges writes the count of current anomalies, and those new or gone since previous.
+func writeChanges(buf *bytes.Buffer, title string, current, previous []string) {
+	in := func(ss []string) map[string]bool {
+		m := make(map[string]bool, len(ss))
+		for _, s := range ss {
+			m[s] = true
+		}
+		return m
+	}
+
+	currentSet, previousSet := in(current), in(previous)
+
+	fmt.Fprintf(buf, "\n%s: %d (previous %d)\n", title, len(current), len(previous))
+
+	for _, s := range current {
+		if !previousSet[s] {
+			fmt.Fprintf(buf, "  new: %s\n", s)
+		}
+	}
+
+	for _, s := range previous {
+		if !currentSet[s] {
+			fmt.Fprintf(buf, "  gone: %s\n", s)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package process
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/loivis/marvel-comics-api-data-loader/maco"
+)
+
+func TestReport_Write(t *testing.T) {
+	dir, err := ioutil.TempDir("", "report")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	previous := NewReport()
+	previous.Started = time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)
+	previous.countMismatch(maco.TypeCharacters, 1, "comics", 3, 2)
+	previous.duplicateComic(10)
+	previous.duplicateComic(11)
+
+	if _, err := previous.Write(dir); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	r := NewReport()
+	r.Started = time.Date(2019, 5, 2, 0, 0, 0, 0, time.UTC)
+	r.countMismatch(maco.TypeCharacters, 1, "comics", 3, 2)
+	r.countMismatch(maco.TypeCharacters, 1, "events", 2, 2)
+	r.duplicateComic(11)
+	r.invalidURI("http://gateway.marvel.com/v1/public/comics/foo")
+
+	summary, err := r.Write(dir)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	for _, want := range []string{
+		"compared to run started at 2019-05-01T00:00:00Z",
+		"available vs total mismatches: 1 (previous 1)\n",
+		"duplicated comic ids: 1 (previous 2)\n  gone: 10\n",
+		"invalid resource uris: 1 (previous 0)\n  new: http://gateway.marvel.com/v1/public/comics/foo\n",
+	} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("got summary %q, want containing %q", summary, want)
+		}
+	}
+
+	for _, name := range []string{"anomalies-20190502T000000Z.json", "anomalies-20190502T000000Z.txt"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("unexpected err: %v", err)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package process
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/loivis/marvel-comics-api-data-loader/client/marvel"
+	"github.com/loivis/marvel-comics-api-data-loader/client/marvel/marveltest"
+	"github.com/loivis/marvel-comics-api-data-loader/maco"
+)
+
+// characterServer returns a fake api serving characters of ids, each modified at modifiedAt(id),
+// and answering with faults as injected in order.
+func characterServer(t *testing.T, ids []int, faults ...*marveltest.Fault) *marveltest.Server {
+	t.Helper()
+
+	s := marveltest.NewServer("private", "public")
+
+	var chars []string
+	for _, id := range ids {
+		chars = append(chars, fmt.Sprintf(`{"id":%d,"modified":%q,"thumbnail":{},"comics":{},"events":{},"series":{},"stories":{}}`, id, modifiedAt(id).Format(marvel.TimeLayout)))
+	}
+
+	if err := s.Load(maco.TypeCharacters, []byte("["+strings.Join(chars, ",")+"]")); err != nil {
+		s.Close()
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	for _, fault := range faults {
+		s.Inject(fault)
+	}
+
+	return s
+}
+
+// clientOf returns a client signing requests as accepted by s.
+func clientOf(s *marveltest.Server) *marvel.Client {
+	return marvel.NewClient(s.URL+marveltest.BasePath, s.PrivateKey, s.PublicKey)
+}
+
+// modifiedAt returns the modified time of entity id served by characterServer, id hours into 2019.
+func modifiedAt(id int) time.Time {
+	return time.Date(2019, 1, 1, id, 0, 0, 0, time.UTC)
+}
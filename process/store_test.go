@@ -0,0 +1,266 @@
+package process
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/loivis/marvel-comics-api-data-loader/maco"
+)
+
+// memStore keeps documents of any collection, their tombstones, the checkpoint and sync marks in memory.
+// Like the mongodb store, tombstoned documents are left out of counts and incomplete ones.
+type memStore struct {
+	maco.Store
+
+	mu         sync.Mutex
+	docs       map[string][]maco.Doc // map of collection to documents
+	checkpoint *maco.Checkpoint
+	marks      map[string]time.Time               // map of collection to sync mark
+	tombstones map[string]map[int]*maco.Tombstone // map of collection to tombstones by id
+	saved      []int                              // ids of docs passed to SaveOne
+	replaced   []int                              // ids of docs passed to ReplaceMany
+}
+
+// get returns doc id of collection, nil if not kept.
+func (ms *memStore) get(collection string, id int) maco.Doc {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if i := ms.index(collection, id); i >= 0 {
+		return ms.docs[collection][i]
+	}
+
+	return nil
+}
+
+// ids returns ids of all docs of collection in ascending order, tombstoned or not.
+func (ms *memStore) ids(collection string) []int {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var ids []int
+	for _, doc := range ms.docs[collection] {
+		ids = append(ids, doc.Identify())
+	}
+	sort.Ints(ids)
+
+	return ids
+}
+
+func (ms *memStore) GetCount(ctx context.Context, collection string) (int, error) {
+	docs, _ := ms.GetDocs(ctx, collection)
+
+	return len(docs), nil
+}
+
+func (ms *memStore) GetDocs(ctx context.Context, collection string, fields ...string) ([]maco.Doc, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var docs []maco.Doc
+	for _, doc := range ms.docs[collection] {
+		if ms.tombstones[collection][doc.Identify()] == nil {
+			docs = append(docs, doc)
+		}
+	}
+
+	return docs, nil
+}
+
+func (ms *memStore) GetIDs(ctx context.Context, collection string) ([]int, error) {
+	return ms.ids(collection), nil
+}
+
+func (ms *memStore) IncompleteDocs(ctx context.Context, collection string) ([]maco.Doc, error) {
+	alive, _ := ms.GetDocs(ctx, collection)
+
+	var docs []maco.Doc
+	for _, doc := range alive {
+		if !reflect.ValueOf(doc).Elem().FieldByName("Intact").Bool() {
+			docs = append(docs, doc)
+		}
+	}
+
+	return docs, nil
+}
+
+func (ms *memStore) IncompleteIDs(ctx context.Context, collection string) ([]int, error) {
+	docs, _ := ms.IncompleteDocs(ctx, collection)
+
+	var ids []int
+	for _, doc := range docs {
+		ids = append(ids, doc.Identify())
+	}
+
+	return ids, nil
+}
+
+func (ms *memStore) GetCheckpoint(ctx context.Context, collection, orderBy string) (*maco.Checkpoint, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.checkpoint == nil {
+		return nil, nil
+	}
+
+	cp := *ms.checkpoint
+	cp.Offsets = append([]int{}, ms.checkpoint.Offsets...)
+
+	return &cp, nil
+}
+
+func (ms *memStore) SaveCheckpoint(ctx context.Context, cp *maco.Checkpoint) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	saved := *cp
+	saved.Offsets = append([]int{}, cp.Offsets...)
+	ms.checkpoint = &saved
+
+	return nil
+}
+
+func (ms *memStore) GetSyncMark(ctx context.Context, collection string) (time.Time, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	return ms.marks[collection], nil
+}
+
+func (ms *memStore) SaveSyncMark(ctx context.Context, collection string, mark time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.marks == nil {
+		ms.marks = make(map[string]time.Time)
+	}
+
+	ms.marks[collection] = mark
+
+	return nil
+}
+
+// SaveCharacters saves characters not kept yet, like the mongodb store.
+func (ms *memStore) SaveCharacters(ctx context.Context, chars []*maco.Character) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, char := range chars {
+		if ms.index(maco.TypeCharacters, char.ID) < 0 {
+			ms.add(maco.TypeCharacters, char)
+		}
+	}
+
+	return nil
+}
+
+// SaveOne records id of doc, and replaces or adds doc without tombstone.
+func (ms *memStore) SaveOne(ctx context.Context, doc maco.Doc) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.saved = append(ms.saved, doc.Identify())
+	ms.replace(collectionOf(doc), doc)
+
+	return nil
+}
+
+// ReplaceMany records ids of docs, and replaces or adds docs without tombstone.
+func (ms *memStore) ReplaceMany(ctx context.Context, collection string, docs []maco.Doc) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, doc := range docs {
+		ms.replaced = append(ms.replaced, doc.Identify())
+		ms.replace(collection, doc)
+	}
+
+	return nil
+}
+
+func (ms *memStore) Tombstone(ctx context.Context, collection string, ids []int, tombstone *maco.Tombstone) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.tombstones == nil {
+		ms.tombstones = make(map[string]map[int]*maco.Tombstone)
+	}
+
+	if ms.tombstones[collection] == nil {
+		ms.tombstones[collection] = make(map[int]*maco.Tombstone)
+	}
+
+	for _, id := range ids {
+		ms.tombstones[collection][id] = tombstone
+	}
+
+	return nil
+}
+
+func (ms *memStore) TombstonedIDs(ctx context.Context, collection string) ([]int, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var ids []int
+	for id := range ms.tombstones[collection] {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	return ids, nil
+}
+
+func (ms *memStore) Revive(ctx context.Context, collection string, ids []int) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, id := range ids {
+		delete(ms.tombstones[collection], id)
+	}
+
+	return nil
+}
+
+func (ms *memStore) add(collection string, doc maco.Doc) {
+	if ms.docs == nil {
+		ms.docs = make(map[string][]maco.Doc)
+	}
+
+	ms.docs[collection] = append(ms.docs[collection], doc)
+}
+
+func (ms *memStore) replace(collection string, doc maco.Doc) {
+	delete(ms.tombstones[collection], doc.Identify())
+
+	if i := ms.index(collection, doc.Identify()); i >= 0 {
+		ms.docs[collection][i] = doc
+		return
+	}
+
+	ms.add(collection, doc)
+}
+
+// index returns the index of doc id in docs of collection, -1 if not kept.
+func (ms *memStore) index(collection string, id int) int {
+	for i, doc := range ms.docs[collection] {
+		if doc.Identify() == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// collectionOf returns the collection of doc by its type.
+func collectionOf(doc maco.Doc) string {
+	for typ, empty := range emptyDocs {
+		if reflect.TypeOf(empty()) == reflect.TypeOf(doc) {
+			return typ
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,161 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/loivis/marvel-comics-api-data-loader/client/marvel"
+	"github.com/loivis/marvel-comics-api-data-loader/maco"
+)
+
+// Sync re-fetches and replaces entities changed since the last successful sync of each collection,
+// then complements the replaced ones with full info.
+// Only types selected with WithTypes are synced, and complementing is skipped unless PhaseComplement is selected.
+func (p *Processor) Sync(ctx context.Context) error {
+	types, run, err := p.plan()
+	if err != nil {
+		return err
+	}
+
+	for _, typ := range types {
+		err := p.syncChanged(ctx, typ)
+		if err != nil {
+			return p.checkQuota(fmt.Errorf("error syncing changed %s: %v", typ, err))
+		}
+
+		if run[PhaseComplement] {
+			err = p.complement(ctx, typ)
+			if err != nil {
+				return p.checkQuota(fmt.Errorf("error complementing %s: %v", typ, err))
+			}
+		}
+
+		log.Info().Str("type", typ).Msg("synced")
+	}
+
+	return nil
+}
+
+// syncChanged replaces documents of the given type modified since the stored sync mark,
+// and moves the mark forward to the latest modified time seen.
+func (p *Processor) syncChanged(ctx context.Context, typ string) error {
+	e, err := entityOf(typ)
+	if err != nil {
+		return err
+	}
+
+	mark, err := p.store.GetSyncMark(ctx, typ)
+	if err != nil {
+		return fmt.Errorf("error getting sync mark: %v", err)
+	}
+
+	if mark.IsZero() {
+		log.Warn().Str("type", typ).Msg("no sync mark, run a full load first")
+		return nil
+	}
+
+	log.Info().Str("type", typ).Time("mark", mark).Msg("syncing changes since mark")
+
+	latest := mark
+	total := 0
+
+	// walk pages one by one in order of modified
+	pager := p.pager(0)
+	pager.Concurrency = 1
+
+	err = pager.Walk(ctx, p.fetchModified(e, mark), func(page marvel.ListPage) error {
+		docs, err := p.convertPage(e, page)
+		if err != nil {
+			return err
+		}
+
+		err = p.store.ReplaceMany(ctx, typ, docs)
+		if err != nil {
+			return fmt.Errorf("error replacing changed %s: %v", typ, err)
+		}
+
+		for _, doc := range docs {
+			if t := modifiedOf(doc); t.After(latest) {
+				latest = t
+			}
+		}
+
+		total += len(docs)
+
+		log.Info().Str("type", typ).Int("count", len(docs)).Msg("replaced changed docs")
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error fetching changed %s: %v", typ, err)
+	}
+
+	if !latest.After(mark) {
+		log.Info().Str("type", typ).Int("count", total).Msg("sync mark unchanged")
+		return nil
+	}
+
+	return p.store.SaveSyncMark(ctx, typ, latest)
+}
+
+// fetchModified returns a marvel.PageFunc over entities of e modified since the given time.
+func (p *Processor) fetchModified(e *entity, since time.Time) marvel.PageFunc {
+	return func(ctx context.Context, offset, limit int) (marvel.ListPage, error) {
+		return e.list(ctx, p.mclient, &marvel.Params{Limit: limit, Offset: offset, OrderBy: "modified", ModifiedSince: since})
+	}
+}
+
+// complement complements incomplete documents of the given type.
+func (p *Processor) complement(ctx context.Context, typ string) error {
+	e, err := entityOf(typ)
+	if err != nil {
+		return err
+	}
+
+	return p.complementAll(ctx, e)
+}
+
+// markIfMissing saves the start time of a full load as sync mark of the given type,
+// unless an earlier sync already left one.
+func (p *Processor) markIfMissing(ctx context.Context, typ string, started time.Time) error {
+	mark, err := p.store.GetSyncMark(ctx, typ)
+	if err != nil {
+		return fmt.Errorf("error getting sync mark: %v", err)
+	}
+
+	if !mark.IsZero() {
+		return nil
+	}
+
+	return p.store.SaveSyncMark(ctx, typ, started)
+}
+
+// modifiedOf returns the parsed modified time of doc, zero if absent or malformed.
+func modifiedOf(doc maco.Doc) time.Time {
+	var s string
+
+	switch v := doc.(type) {
+	case *maco.Character:
+		s = v.Modified
+	case *maco.Comic:
+		s = v.Modified
+	case *maco.Creator:
+		s = v.Modified
+	case *maco.Event:
+		s = v.Modified
+	case *maco.Series:
+		s = v.Modified
+	case *maco.Story:
+		s = v.Modified
+	}
+
+	t, err := time.Parse(marvel.TimeLayout, s)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
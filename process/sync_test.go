@@ -0,0 +1,121 @@
+package process
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/loivis/marvel-comics-api-data-loader/client/marvel/marveltest"
+	"github.com/loivis/marvel-comics-api-data-loader/maco"
+)
+
+func Test_ModifiedOf(t *testing.T) {
+	for _, tc := range []struct {
+		desc string
+		doc  maco.Doc
+		want time.Time
+	}{
+		{
+			desc: "Comic",
+			doc:  &maco.Comic{Modified: "2019-05-01T08:30:00-0400"},
+			want: time.Date(2019, 5, 1, 12, 30, 0, 0, time.UTC),
+		},
+		{
+			desc: "Story",
+			doc:  &maco.Story{Modified: "2014-04-29T14:18:17-0400"},
+			want: time.Date(2014, 4, 29, 18, 18, 17, 0, time.UTC),
+		},
+		{
+			desc: "Empty",
+			doc:  &maco.Character{},
+			want: time.Time{},
+		},
+		{
+			desc: "Malformed",
+			doc:  &maco.Event{Modified: "foo"},
+			want: time.Time{},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got, want := modifiedOf(tc.doc), tc.want; !got.Equal(want) {
+				t.Errorf("got modified %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestProcessor_Sync(t *testing.T) {
+	// characters 1 to 5 modified an hour apart, in pages of 2
+	failPage := &marveltest.Fault{
+		Match:  func(r *http.Request) bool { return r.URL.Query().Get("offset") == "2" },
+		Status: http.StatusConflict,
+	}
+
+	for _, tc := range []struct {
+		desc         string
+		mark         time.Time
+		fault        *marveltest.Fault
+		wantErr      bool
+		wantReplaced []int
+		wantMark     time.Time
+	}{
+		{
+			desc:         "Changed",
+			mark:         modifiedAt(2),
+			wantReplaced: []int{2, 3, 4, 5},
+			wantMark:     modifiedAt(5),
+		},
+		{
+			desc:         "PageFailed",
+			mark:         modifiedAt(2),
+			fault:        failPage,
+			wantErr:      true,
+			wantReplaced: []int{2, 3},
+			wantMark:     modifiedAt(2),
+		},
+		{
+			desc: "NoMark",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			var faults []*marveltest.Fault
+			if tc.fault != nil {
+				faults = append(faults, tc.fault)
+			}
+
+			s := characterServer(t, []int{1, 2, 3, 4, 5}, faults...)
+			defer s.Close()
+
+			store := &memStore{}
+			for id := 1; id <= 5; id++ {
+				store.SaveCharacters(context.Background(), []*maco.Character{{ID: id, Intact: true}})
+			}
+			if !tc.mark.IsZero() {
+				store.SaveSyncMark(context.Background(), maco.TypeCharacters, tc.mark)
+			}
+
+			p := NewProcessor(clientOf(s), store, "", "", WithTypes(maco.TypeCharacters))
+			p.limit = 2
+
+			err := p.Sync(context.Background())
+			if got, want := err != nil, tc.wantErr; got != want {
+				t.Fatalf("got err %v, want error %t", err, want)
+			}
+
+			if got, want := store.replaced, tc.wantReplaced; !reflect.DeepEqual(got, want) {
+				t.Errorf("got replaced %v, want %v", got, want)
+			}
+
+			mark, _ := store.GetSyncMark(context.Background(), maco.TypeCharacters)
+			if got, want := mark, tc.wantMark; !got.Equal(want) {
+				t.Errorf("got sync mark %v, want %v", got, want)
+			}
+
+			if got := store.get(maco.TypeCharacters, 1).(*maco.Character).Modified; got != "" {
+				t.Errorf("got character 1 replaced, modified %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/loivis/marvel-comics-api-data-loader/maco"
+)
+
+// Reasons of tombstoning entities removed upstream.
+const (
+	reasonUnlisted = "unlisted"  // not in a complete enumeration
+	reasonNotFound = "not found" // 404 when fetched by id
+)
+
+// tombstone marks documents of ids of e as removed upstream for reason, and reports them.
+// Documents are kept rather than deleted.
+func (p *Processor) tombstone(ctx context.Context, e *entity, ids []int, reason string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	err := p.store.Tombstone(ctx, e.typ, ids, &maco.Tombstone{Reason: reason, Removed: time.Now().UTC()})
+	if err != nil {
+		return fmt.Errorf("error tombstoning %s %v: %v", e.typ, ids, err)
+	}
+
+	for _, id := range ids {
+		p.report.removal(e.typ, id, reason)
+	}
+
+	log.Warn().Str("type", e.typ).Ints("ids", ids).Str("reason", reason).Msgf("tombstoned %s removed upstream", e.typ)
+
+	return nil
+}
+
+// revive removes tombstones of documents of ids of e, listed upstream again.
+func (p *Processor) revive(ctx context.Context, e *entity, ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := p.store.Revive(ctx, e.typ, ids); err != nil {
+		return fmt.Errorf("error reviving %s %v: %v", e.typ, ids, err)
+	}
+
+	log.Info().Str("type", e.typ).Ints("ids", ids).Msgf("revived %s listed again", e.typ)
+
+	return nil
+}
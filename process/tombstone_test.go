@@ -0,0 +1,60 @@
+package process
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/loivis/marvel-comics-api-data-loader/client/marvel"
+	"github.com/loivis/marvel-comics-api-data-loader/client/marvel/marveltest"
+	"github.com/loivis/marvel-comics-api-data-loader/maco"
+)
+
+func TestProcessor_Tombstone(t *testing.T) {
+	// characters 1, 2 and 5 listed, 5 not found by id
+	s := characterServer(t, []int{1, 2, 5}, &marveltest.Fault{
+		Match:  func(r *http.Request) bool { return strings.HasSuffix(r.URL.Path, "/characters/5") },
+		Status: http.StatusNotFound,
+	})
+	defer s.Close()
+
+	// character 2 tombstoned before, e.g. on a one-off 404, and listed again
+	store := &memStore{tombstones: map[string]map[int]*maco.Tombstone{maco.TypeCharacters: {2: {Reason: reasonNotFound}}}}
+	for _, id := range []int{1, 2, 3, 4} {
+		store.SaveCharacters(context.Background(), []*maco.Character{{ID: id}})
+	}
+
+	p := NewProcessor(clientOf(s), store, "", "")
+	p.limit = 10
+
+	err := p.loadUnlisted(context.Background(), characterEntity)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	reasons := make(map[int]string)
+	for id, tombstone := range store.tombstones[maco.TypeCharacters] {
+		reasons[id] = tombstone.Reason
+	}
+
+	if got, want := reasons, map[int]string{3: reasonUnlisted, 4: reasonUnlisted, 5: reasonNotFound}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got tombstones %v, want %v", got, want)
+	}
+
+	if got, want := store.get(maco.TypeCharacters, 2).(*maco.Character).Modified, modifiedAt(2).Format(marvel.TimeLayout); got != want {
+		t.Errorf("got revived character modified %q, want %q", got, want)
+	}
+
+	var removals []string
+	for _, rm := range p.report.Removals {
+		removals = append(removals, rm.String())
+	}
+	sort.Strings(removals)
+
+	if got, want := removals, []string{"characters 3: unlisted", "characters 4: unlisted", "characters 5: not found"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got removals %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,46 @@
+package process
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+)
+
+// verify compares the count of stored documents of e with the count from api,
+// and checks that no stored document is left incomplete.
+// It returns whether both hold.
+func (p *Processor) verify(ctx context.Context, e *entity) (bool, error) {
+	local, err := p.store.GetCount(ctx, e.typ)
+	if err != nil {
+		return false, fmt.Errorf("error getting local %s count: %v", e.name, err)
+	}
+
+	remote, err := p.mclient.GetCount(ctx, e.typ)
+	if err != nil {
+		return false, fmt.Errorf("error getting remote %s count: %v", e.name, err)
+	}
+
+	incomplete, err := p.store.IncompleteIDs(ctx, e.typ)
+	if err != nil {
+		return false, fmt.Errorf("error get imcomplete %s ids: %v", e.name, err)
+	}
+
+	ok := true
+
+	if local != remote {
+		log.Warn().Str("type", e.typ).Int("local", local).Int("remote", remote).Msgf("%s count differs from api", e.name)
+		ok = false
+	}
+
+	if len(incomplete) > 0 {
+		log.Warn().Str("type", e.typ).Int("count", len(incomplete)).Msgf("incomplete %s left", e.name)
+		ok = false
+	}
+
+	if ok {
+		log.Info().Str("type", e.typ).Int("count", local).Msgf("verified %s", e.name)
+	}
+
+	return ok, nil
+}